@@ -0,0 +1,208 @@
+package goji
+
+import "fmt"
+
+/*
+ConflictKind describes the way in which two routes conflict.
+*/
+type ConflictKind int
+
+const (
+	// Unreachable indicates that the later route will never be routed
+	// to, since every request it matches is also matched by earlier
+	// routes.
+	Unreachable ConflictKind = iota
+	// Ambiguous indicates that some, but not all, requests matched by
+	// either route are also matched by the other, and so which route a
+	// request is dispatched to depends on the order in which they were
+	// registered.
+	Ambiguous
+)
+
+func (k ConflictKind) String() string {
+	switch k {
+	case Unreachable:
+		return "unreachable"
+	case Ambiguous:
+		return "ambiguous"
+	default:
+		return fmt.Sprintf("ConflictKind(%d)", int(k))
+	}
+}
+
+/*
+Conflict describes a pair of routes registered on the same Mux which interfere
+with each other. Earlier always has a smaller Index than Later.
+
+For Unreachable conflicts, Earlier is a route that matches requests which Later
+would otherwise have matched. If Later is made unreachable by the combination of
+several earlier routes (for instance, a GET route and a POST route shadowing a
+route for both methods), Earlier is the last of them.
+*/
+type Conflict struct {
+	Kind    ConflictKind
+	Earlier Route
+	Later   Route
+}
+
+func (c Conflict) String() string {
+	if c.Kind == Unreachable {
+		return fmt.Sprintf("route %d (%v) is unreachable: shadowed by route %d (%v)",
+			c.Later.Index, c.Later.Pattern, c.Earlier.Index, c.Earlier.Pattern)
+	}
+	return fmt.Sprintf("route %d (%v) ambiguously overlaps route %d (%v)",
+		c.Later.Index, c.Later.Pattern, c.Earlier.Index, c.Earlier.Pattern)
+}
+
+/*
+Conflicts analyzes the routes registered on the Mux, and returns every pair of
+routes that conflict, ordered by the index of the later route. Conflicts are
+reported either when a route can never be reached because earlier routes match
+every request it would, or when two routes overlap ambiguously, that is when
+each matches some but not all of the requests the other does.
+
+Note that it is common (and harmless) for a route to match a subset of the
+requests a later route matches: for instance, "/users/new" is often registered
+before "/users/:name". This is not reported as a conflict.
+
+Analysis is only possible for Patterns which are able to compare themselves to
+one another, which Goji's pat subpackage does using the CoversPath and
+OverlapsPath methods. HTTP methods are compared using the HTTPMethods
+optimization (see the documentation for Pattern). Routes with Patterns that do
+not support these comparisons are never reported. Routes in Groups are compared
+using their own Patterns when they share the same prefix Patterns, and otherwise
+as a whole, which requires their prefix Patterns to be able to join themselves
+to the Patterns that follow (as pat's Patterns ending in wildcards do, using
+JoinPath).
+
+Conflicts does not descend into other Muxes registered as route Handlers.
+*/
+func (m *Mux) Conflicts() []Conflict {
+	routes := m.Routes()
+	var out []Conflict
+	for i := range routes {
+		out = append(out, conflicts(routes, i)...)
+	}
+	return out
+}
+
+/*
+Strict controls whether the Mux rejects conflicting routes. When enabled, Handle
+(and its variants) will panic if the route being added conflicts with any route
+already registered on the Mux, as determined by Conflicts. Strict mode is
+disabled by default, and only applies to routes added after it is enabled.
+*/
+func (m *Mux) Strict(enabled bool) {
+	m.update(func(m *Mux) {
+		m.strict = enabled
+	})
+}
+
+// conflicts returns the conflicts between the route with the given index and
+// all routes that precede it.
+func conflicts(routes []Route, idx int) []Conflict {
+	if _, ok := routes[idx].Pattern.(pathRelations); !ok {
+		return nil
+	}
+	laterMethods := methodsOf(routes[idx].Pattern)
+
+	var out []Conflict
+	// For each of the later route's methods, the earlier route which
+	// covers it (if any). A nil set of methods is represented by "".
+	covered := make(map[string]int)
+	for i := 0; i < idx; i++ {
+		p := routes[i].Pattern
+		earlier, later, ok := relations(p, routes[idx].Pattern)
+		if !ok {
+			continue
+		}
+		methods := methodsOf(p)
+		if !intersects(methods, laterMethods) {
+			continue
+		}
+
+		if earlier.CoversPath(later) {
+			if laterMethods == nil {
+				if methods == nil {
+					covered[""] = i
+				}
+				continue
+			}
+			for method := range laterMethods {
+				if _, ok := covered[method]; !ok && contains(methods, method) {
+					covered[method] = i
+				}
+			}
+			continue
+		}
+		if later.CoversPath(earlier) {
+			// The common case of a more specific route preceding a
+			// more general one.
+			continue
+		}
+		if later.OverlapsPath(earlier) {
+			out = append(out, conflict(Ambiguous, routes, i, idx))
+		}
+	}
+
+	_, all := covered[""]
+	if all || (len(covered) > 0 && len(covered) == len(laterMethods)) {
+		last := -1
+		for _, i := range covered {
+			if i > last {
+				last = i
+			}
+		}
+		out = append(out, conflict(Unreachable, routes, last, idx))
+	}
+	return out
+}
+
+// relations returns forms of the given Patterns which can be compared with one
+// another. Routes in Groups can only be compared to other routes as Groups, so
+// if either Pattern belongs to one, both are treated as such.
+func relations(p, q Pattern) (pathRelations, pathRelations, bool) {
+	_, pg := p.(*groupPattern)
+	_, qg := q.(*groupPattern)
+	if pg && !qg {
+		q = &groupPattern{inner: q}
+	} else if qg && !pg {
+		p = &groupPattern{inner: p}
+	}
+	pr, pok := p.(pathRelations)
+	qr, qok := q.(pathRelations)
+	return pr, qr, pok && qok
+}
+
+func conflict(kind ConflictKind, routes []Route, earlier, later int) Conflict {
+	return Conflict{Kind: kind, Earlier: routes[earlier], Later: routes[later]}
+}
+
+func methodsOf(p Pattern) map[string]struct{} {
+	if hm, ok := p.(httpMethods); ok {
+		return hm.HTTPMethods()
+	}
+	return nil
+}
+
+// contains reports whether the given set of methods, where nil stands for all
+// methods, contains the given method.
+func contains(methods map[string]struct{}, method string) bool {
+	if methods == nil {
+		return true
+	}
+	_, ok := methods[method]
+	return ok
+}
+
+func intersects(a, b map[string]struct{}) bool {
+	if a == nil || b == nil {
+		return true
+	}
+	for method := range a {
+		if _, ok := b[method]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,177 @@
+package goji
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"goji.io/pat"
+)
+
+// relPattern is a Pattern which matches a fixed set of space-separated paths.
+type relPattern struct {
+	methods string
+	paths   string
+}
+
+func (relPattern) Match(r *http.Request) *http.Request {
+	return nil
+}
+
+func (p relPattern) HTTPMethods() map[string]struct{} {
+	if p.methods == "" {
+		return nil
+	}
+	m := make(map[string]struct{})
+	for _, method := range strings.Fields(p.methods) {
+		m[method] = struct{}{}
+	}
+	return m
+}
+
+func (p relPattern) CoversPath(other interface{}) bool {
+	for _, path := range strings.Fields(other.(relPattern).paths) {
+		if !strings.Contains(" "+p.paths+" ", " "+path+" ") {
+			return false
+		}
+	}
+	return true
+}
+
+func (p relPattern) OverlapsPath(other interface{}) bool {
+	for _, path := range strings.Fields(other.(relPattern).paths) {
+		if strings.Contains(" "+p.paths+" ", " "+path+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+var ConflictRoutes = []Pattern{
+	relPattern{"", "/a /b"},
+	relPattern{"GET", "/a"},
+	relPattern{"GET", "/c"},
+	relPattern{"POST", "/c /d"},
+	relPattern{"GET POST", "/c"},
+	relPattern{"PUT", "/a /c"},
+	relPattern{"", "/d /e"},
+	relPattern{"", "/a /b /c /d /e"},
+	boolPattern(true),
+}
+
+func TestConflicts(t *testing.T) {
+	t.Parallel()
+
+	m := NewMux()
+	for i, p := range ConflictRoutes {
+		m.Handle(p, intHandler(i))
+	}
+
+	type pair struct {
+		kind           ConflictKind
+		earlier, later int
+	}
+	var actual []pair
+	for _, c := range m.Conflicts() {
+		actual = append(actual, pair{c.Kind, c.Earlier.Index, c.Later.Index})
+	}
+	expected := []pair{
+		{Unreachable, 0, 1},
+		{Unreachable, 3, 4},
+		{Ambiguous, 0, 5},
+		{Ambiguous, 3, 6},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestStrict(t *testing.T) {
+	t.Parallel()
+
+	m := NewMux()
+	m.Strict(true)
+	m.Handle(relPattern{"", "/a"}, intHandler(0))
+	m.Handle(relPattern{"", "/a /b"}, intHandler(1))
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected conflicting route to panic")
+		}
+		if n := len(m.Routes()); n != 2 {
+			t.Errorf("expected 2 routes, got %d", n)
+		}
+	}()
+	m.Handle(relPattern{"GET", "/b"}, intHandler(2))
+}
+
+func TestConflictsGroup(t *testing.T) {
+	t.Parallel()
+
+	m := NewMux()
+	api := m.Group(pat.New("/api/:version/*"))
+	api.Handle(pat.Get("/:a"), intHandler(0))
+	api.Handle(pat.Get("/:b"), intHandler(1))
+	m.Handle(pat.Get("/api/v1/users"), intHandler(2))
+	api.Handle(pat.Get("/users/:id"), intHandler(3))
+	m.Handle(pat.Get("/api/v2/*"), intHandler(4))
+	m.Group(pat.New("/api/*")).Group(pat.New("/:v/*")).Handle(pat.Get("/:c"), intHandler(5))
+	api.Handle(boolPattern(true), intHandler(6))
+
+	type pair struct {
+		kind           ConflictKind
+		earlier, later int
+	}
+	var actual []pair
+	for _, c := range m.Conflicts() {
+		actual = append(actual, pair{c.Kind, c.Earlier.Index, c.Later.Index})
+	}
+	expected := []pair{
+		{Unreachable, 0, 1},
+		{Unreachable, 0, 2},
+		{Ambiguous, 0, 4},
+		{Ambiguous, 1, 4},
+		{Ambiguous, 3, 4},
+		{Ambiguous, 4, 5},
+		{Unreachable, 0, 5},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestStrictGroup(t *testing.T) {
+	t.Parallel()
+
+	m := NewMux()
+	m.Strict(true)
+	g := m.Group(pat.New("/users/*"))
+	g.Handle(pat.Get("/:name"), intHandler(0))
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected conflicting route to panic")
+		}
+	}()
+	g.Handle(pat.Get("/:id"), intHandler(1))
+}
+
+func benchmarkStrict(b *testing.B, n int) {
+	patterns := make([]Pattern, n)
+	for i := range patterns {
+		patterns[i] = pat.Get(fmt.Sprintf("/api/:tenant/r%d/:id", i))
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m := NewMux()
+		m.Strict(true)
+		for _, p := range patterns {
+			m.Handle(p, intHandler(0))
+		}
+	}
+}
+
+func BenchmarkStrict100(b *testing.B)  { benchmarkStrict(b, 100) }
+func BenchmarkStrict1000(b *testing.B) { benchmarkStrict(b, 1000) }
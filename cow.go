@@ -0,0 +1,88 @@
+package goji
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// live holds the published configuration of a Mux in copy-on-write mode.
+type live struct {
+	// mu serializes writers. Readers never take it.
+	mu sync.Mutex
+	// snapshot holds the current immutable *Mux.
+	snapshot atomic.Value
+}
+
+/*
+CopyOnWrite switches the Mux into copy-on-write mode, after which it is safe to
+configure the Mux (for instance, with Handle, Use, or NotFound) from multiple
+goroutines and concurrently with requests.
+
+In copy-on-write mode, every configuration change builds a new copy of the
+Mux's routing table and middleware stack, and then atomically publishes it.
+Requests are routed using whichever copy was current when they arrived: in
+particular, in-flight requests are unaffected by configuration changes made
+while they are being served. Routing itself takes no locks, and costs one
+atomic load more than it otherwise would.
+
+Since every change copies the routing table, configuring a Mux in copy-on-write
+mode is considerably slower than configuring an ordinary Mux. It is also
+important to note that middleware functions passed to Use are called again
+every time a new middleware stack is built, and that those passed to UsePre are
+called again after every change.
+
+CopyOnWrite must be called before the Mux is shared between goroutines. Calling
+it more than once has no further effect.
+*/
+func (m *Mux) CopyOnWrite() {
+	if m.live != nil {
+		return
+	}
+	l := new(live)
+	l.snapshot.Store(m.clone())
+	m.live = l
+}
+
+// current returns the Mux that should be used to serve requests: either the
+// Mux itself, or the most recently published snapshot in copy-on-write mode.
+func (m *Mux) current() *Mux {
+	if m.live == nil {
+		return m
+	}
+	return m.live.snapshot.Load().(*Mux)
+}
+
+// update applies a configuration change to the Mux. In copy-on-write mode, the
+// change is applied to a copy of the current snapshot, which is then published.
+func (m *Mux) update(fn func(m *Mux)) {
+	if m.live == nil {
+		fn(m)
+		return
+	}
+
+	m.live.mu.Lock()
+	defer m.live.mu.Unlock()
+	next := m.current().clone()
+	fn(next)
+	m.live.snapshot.Store(next)
+}
+
+// clone returns a copy of the Mux which shares no mutable state with the
+// original. The copy is never in copy-on-write mode.
+func (m *Mux) clone() *Mux {
+	c := *m
+	c.live = nil
+	c.middleware = append([]func(http.Handler) http.Handler(nil), m.middleware...)
+	c.pre = append([]func(http.Handler) http.Handler(nil), m.pre...)
+	c.entries = append([]entry(nil), m.entries...)
+	if m.names != nil {
+		c.names = make(map[string]*Registration, len(m.names))
+		for name, reg := range m.names {
+			c.names[name] = reg
+		}
+	}
+	c.router = m.router.clone()
+	c.buildPre()
+	return &c
+}
@@ -0,0 +1,65 @@
+package goji
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+)
+
+func TestCopyOnWriteSnapshot(t *testing.T) {
+	t.Parallel()
+
+	m := NewMux()
+	m.CopyOnWrite()
+	ch := make(chan string, 10)
+	m.Use(makeMiddleware(ch, "one"))
+	m.HandleFunc(testPattern{mark: new(int), prefix: "/"}, func(w http.ResponseWriter, r *http.Request) {
+		// Reconfigure the Mux from within a request. This must not
+		// affect the request currently being served.
+		m.Use(makeMiddleware(ch, "two"))
+		m.Handle(boolPattern(true), intHandler(0))
+		ch <- "handler"
+	})
+
+	w, r := wr()
+	m.ServeHTTP(w, r)
+	expectSequence(t, ch, "before one", "handler", "after one")
+
+	w, r = wr()
+	m.ServeHTTP(w, r)
+	expectSequence(t, ch, "before one", "before two", "handler", "after two", "after one")
+
+	if n := len(m.Routes()); n != 3 {
+		t.Errorf("expected 3 routes, got %d", n)
+	}
+}
+
+func TestCopyOnWriteConcurrent(t *testing.T) {
+	t.Parallel()
+
+	m := NewMux()
+	m.CopyOnWrite()
+	mark := new(int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				m.Handle(testPattern{mark: mark, methods: []string{"GET"}, prefix: "/a"}, intHandler(j))
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				m.ServeHTTP(wr())
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := len(m.Routes()); n != 200 {
+		t.Errorf("expected 200 routes, got %d", n)
+	}
+}
@@ -6,14 +6,64 @@ import (
 	"goji.io/internal"
 )
 
-type dispatch struct{}
+type dispatch struct {
+	notFound      http.Handler
+	autoOptions   bool
+	profileLabels bool
+}
 
 func (d dispatch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	h := ctx.Value(internal.Handler)
-	if h == nil {
-		http.NotFound(w, r)
-	} else {
-		h.(http.Handler).ServeHTTP(w, r)
+	if h != nil {
+		if d.profileLabels {
+			serveLabeled(h.(http.Handler), w, r)
+		} else {
+			h.(http.Handler).ServeHTTP(w, r)
+		}
+		return
+	}
+
+	if loc, ok := ctx.Value(redirectKey).(string); ok {
+		redirect(w, r, loc)
+		return
+	}
+
+	allowed, _ := ctx.Value(allowedKey).(map[string]struct{})
+	if len(allowed) == 0 {
+		if d.notFound != nil {
+			d.notFound.ServeHTTP(w, r)
+		} else {
+			http.NotFound(w, r)
+		}
+		return
+	}
+
+	if d.autoOptions {
+		allowed = withOptions(allowed)
+		if r.Method == "OPTIONS" {
+			w.Header().Set("Allow", allowHeader(allowed))
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	methodNotAllowed(w, allowed)
+}
+
+// withOptions returns a copy of the given set of methods that also includes
+// OPTIONS.
+func withOptions(methods map[string]struct{}) map[string]struct{} {
+	out := make(map[string]struct{}, len(methods)+1)
+	for method := range methods {
+		out[method] = struct{}{}
 	}
+	out["OPTIONS"] = struct{}{}
+	return out
+}
+
+// methodNotAllowed replies to the request with an HTTP 405 method not allowed
+// error, advertising the given methods in the Allow header.
+func methodNotAllowed(w http.ResponseWriter, allowed map[string]struct{}) {
+	w.Header().Set("Allow", allowHeader(allowed))
+	http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
 }
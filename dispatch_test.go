@@ -29,4 +29,16 @@ func TestDispatch(t *testing.T) {
 	if w.Code != 123 {
 		t.Errorf("status: expected %d, got %d", 123, w.Code)
 	}
+
+	w, r = wr()
+	allowed := map[string]struct{}{"POST": {}, "PUT": {}}
+	ctx = context.WithValue(context.Background(), allowedKey, allowed)
+	r = r.WithContext(ctx)
+	d.ServeHTTP(w, r)
+	if w.Code != 405 {
+		t.Errorf("status: expected %d, got %d", 405, w.Code)
+	}
+	if allow := w.Header().Get("Allow"); allow != "POST, PUT" {
+		t.Errorf("Allow: expected %q, got %q", "POST, PUT", allow)
+	}
 }
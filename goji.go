@@ -18,11 +18,11 @@ Goji's "pat" sub-package, which implements a small domain specific language for
 HTTP routing.
 
 Patterns typically only examine a small portion of incoming requests, most
-commonly the HTTP method and the URL's RawPath. As an optimization, Goji can
-elide calls to your Pattern for requests it knows cannot match. Pattern authors
-who wish to take advantage of this functionality (and in some cases an
-asymptotic performance improvement) can augment their Pattern implementations
-with any of the following methods:
+commonly the HTTP method, the URL's RawPath, and occasionally the Host. As an
+optimization, Goji can elide calls to your Pattern for requests it knows cannot
+match. Pattern authors who wish to take advantage of this functionality (and in
+some cases an asymptotic performance improvement) can augment their Pattern
+implementations with any of the following methods:
 
 	// HTTPMethods returns a set of HTTP methods that this Pattern matches,
 	// or nil if it's not possible to determine which HTTP methods might be
@@ -36,6 +36,23 @@ with any of the following methods:
 	// guaranteed to never match this Pattern.
 	PathPrefix() string
 
+	// PathSegments returns a description of the structure of all RawPaths
+	// that match this Pattern, as a sequence of literal strings, variables,
+	// and wildcards. Put another way, requests with RawPaths that cannot
+	// be produced by the returned Segments are guaranteed to never match
+	// this Pattern. See the documentation for the Segment type in Goji's
+	// "pattern" subpackage for more. When this optimization is available,
+	// Goji prefers it to PathPrefix.
+	PathSegments() []pattern.Segment
+
+	// Host returns the host name that all requests which match this
+	// Pattern must have or, if it begins with a ".", a suffix that all such
+	// host names must have. The empty string indicates that it is not
+	// possible to determine which hosts might be matched. Host names are
+	// compared with the value returned by pattern.Host, and so must be in
+	// lower case and must not contain a port.
+	Host() string
+
 The presence or lack of these performance improvements should be viewed as an
 implementation detail and are not part of Goji's API compatibility guarantee. It
 is the responsibility of Pattern authors to ensure that their Match function
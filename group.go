@@ -0,0 +1,285 @@
+package goji
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"goji.io/internal"
+	"goji.io/pattern"
+)
+
+/*
+Group is a set of routes which share a common Pattern prefix and middleware
+stack, but which are nevertheless registered directly on a Mux. Groups are
+created with Mux.Group.
+
+A Group behaves much like a SubMux registered on the Group's prefix Pattern.
+For instance, the following two snippets route requests identically, and in both
+cases bind the same variables:
+
+	api := goji.SubMux()
+	api.Use(requireToken)
+	api.HandleFunc(pat.Get("/users/:name"), user)
+	root.Handle(pat.New("/api/:version/*"), api)
+
+	api := root.Group(pat.New("/api/:version/*"))
+	api.Use(requireToken)
+	api.HandleFunc(pat.Get("/users/:name"), user)
+
+However, where the SubMux requires a second routing pass (and a second layer of
+routing information in the request's context) for each request, routes in a
+Group are flattened into the Mux's own routing table. For every route in a
+Group, the Mux first matches the Group's prefix Pattern, and then (in the
+context returned by the prefix) the route's own Pattern. middleware.Pattern
+reports the route's own Pattern, just as it would inside of a SubMux. Unlike a
+SubMux, a Group does not have its own NotFound handler: requests that match the
+prefix but none of the Group's routes continue to be routed by the Mux.
+
+Group middleware is called after the Mux's middleware stack and before any
+route middleware (see Registration.Use). Routes in a Group keep their place in
+the Mux's routing order: they are interleaved with routes registered directly on
+the Mux in the order they were added, and are compared with them by Conflicts
+(and in Strict mode) like any other route.
+*/
+type Group struct {
+	mux        *Mux
+	parent     *Group
+	prefix     Pattern
+	middleware []func(http.Handler) http.Handler
+}
+
+/*
+Group returns a new Group of routes which all match the given prefix Pattern.
+See the documentation for Group for more.
+*/
+func (m *Mux) Group(prefix Pattern) *Group {
+	return &Group{mux: m, prefix: prefix}
+}
+
+/*
+Group returns a new Group nested inside of this one. Its routes must match both
+this Group's prefix and the given prefix, and are subject to this Group's
+middleware as well as their own.
+*/
+func (g *Group) Group(prefix Pattern) *Group {
+	return &Group{mux: g.mux, parent: g, prefix: prefix}
+}
+
+/*
+Handle adds a new route to the Group's Mux, which matches requests that match
+both the Group's prefix and the given Pattern. See Mux.Handle for more.
+*/
+func (g *Group) Handle(p Pattern, h http.Handler) *Registration {
+	return g.mux.register(g, "", &groupPattern{g.prefixes(), p}, h)
+}
+
+/*
+HandleFunc adds a new route to the Group's Mux. It is equivalent to calling
+Handle on a handler wrapped with http.HandlerFunc, and is provided only for
+convenience.
+*/
+func (g *Group) HandleFunc(p Pattern, h func(http.ResponseWriter, *http.Request)) *Registration {
+	return g.Handle(p, http.HandlerFunc(h))
+}
+
+/*
+Use appends a middleware to the Group's middleware stack, which applies to every
+route in the Group (including those added before the call to Use) and to the
+routes of every Group nested within it. See Mux.Use for more.
+*/
+func (g *Group) Use(middleware func(http.Handler) http.Handler) {
+	g.mux.update(func(m *Mux) {
+		g.middleware = append(g.middleware, middleware)
+		for i := range m.entries {
+			e := &m.entries[i]
+			if e.reg.group.within(g) {
+				e.group = e.reg.group.chain()
+				m.router.setHandler(i, e.compile())
+			}
+		}
+	})
+}
+
+// chain returns the middleware of the Group and all of its parents, outermost
+// first. The returned slice is never shared.
+func (g *Group) chain() []func(http.Handler) http.Handler {
+	if g == nil {
+		return nil
+	}
+	return append(g.parent.chain(), g.middleware...)
+}
+
+// prefixes returns the prefix Patterns of the Group and all of its parents,
+// outermost first.
+func (g *Group) prefixes() []Pattern {
+	if g == nil {
+		return nil
+	}
+	return append(g.parent.prefixes(), g.prefix)
+}
+
+// within reports whether g is ancestor or one of its descendants.
+func (g *Group) within(ancestor *Group) bool {
+	for ; g != nil; g = g.parent {
+		if g == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+// groupPattern is the Pattern used to register routes in a Group.
+type groupPattern struct {
+	prefixes []Pattern
+	inner    Pattern
+}
+
+func (g *groupPattern) Match(r *http.Request) *http.Request {
+	for _, p := range g.prefixes {
+		if r = p.Match(r); r == nil {
+			return nil
+		}
+	}
+	return g.inner.Match(r)
+}
+
+// HTTPMethods implements the HTTPMethods optimization. Since every Pattern must
+// match, the result is the intersection of all known method sets.
+func (g *groupPattern) HTTPMethods() map[string]struct{} {
+	var methods map[string]struct{}
+	for _, p := range append(g.prefixes[:len(g.prefixes):len(g.prefixes)], g.inner) {
+		hm, ok := p.(httpMethods)
+		if !ok || hm.HTTPMethods() == nil {
+			continue
+		}
+		if methods == nil {
+			methods = hm.HTTPMethods()
+			continue
+		}
+		both := make(map[string]struct{})
+		for method := range hm.HTTPMethods() {
+			if _, ok := methods[method]; ok {
+				both[method] = struct{}{}
+			}
+		}
+		methods = both
+	}
+	return methods
+}
+
+// PathPrefix implements the PathPrefix optimization. Only the outermost prefix
+// sees the Mux's path, so it alone determines the path prefix.
+func (g *groupPattern) PathPrefix() string {
+	if pp, ok := g.prefixes[0].(pathPrefix); ok {
+		return pp.PathPrefix()
+	}
+	return ""
+}
+
+// PathSegments implements the PathSegments optimization, describing the paths
+// matched by the route's Patterns joined into one (see joined). If they cannot
+// be joined, it returns nil, and the router falls back on the other
+// optimizations.
+func (g *groupPattern) PathSegments() []pattern.Segment {
+	if ps, ok := g.joined().(pathSegments); ok {
+		return ps.PathSegments()
+	}
+	return nil
+}
+
+// Host implements the Host optimization. Since no Pattern changes the request's
+// host, any of them can provide it.
+func (g *groupPattern) Host() string {
+	for _, p := range append(g.prefixes[:len(g.prefixes):len(g.prefixes)], g.inner) {
+		if h, ok := p.(host); ok && h.Host() != "" {
+			return h.Host()
+		}
+	}
+	return ""
+}
+
+// String joins the Patterns' templates, as middleware.Template would.
+func (g *groupPattern) String() string {
+	parts := make([]string, 0, len(g.prefixes)+1)
+	for _, p := range g.prefixes {
+		parts = append(parts, fmt.Sprint(p))
+	}
+	parts = append(parts, fmt.Sprint(g.inner))
+	return internal.Template(parts)
+}
+
+// CoversPath implements Conflicts analysis for routes in Groups. See relate.
+func (g *groupPattern) CoversPath(other interface{}) bool {
+	p, q := g.relate(other)
+	return p != nil && p.CoversPath(q)
+}
+
+// OverlapsPath implements Conflicts analysis for routes in Groups. See relate.
+func (g *groupPattern) OverlapsPath(other interface{}) bool {
+	p, q := g.relate(other)
+	return p != nil && p.OverlapsPath(q)
+}
+
+// relate returns the Patterns to compare in order to compare g to other, or a
+// nil pathRelations if they cannot be compared. Routes with identical prefixes
+// are compared using their own Patterns. Otherwise, each route's Patterns are
+// joined into one (see pathJoiner) and compared as a whole.
+func (g *groupPattern) relate(other interface{}) (pathRelations, interface{}) {
+	o, ok := other.(*groupPattern)
+	if !ok {
+		p, ok := other.(Pattern)
+		if !ok {
+			return nil, nil
+		}
+		o = &groupPattern{inner: p}
+	}
+	if samePatterns(g.prefixes, o.prefixes) {
+		p, _ := g.inner.(pathRelations)
+		return p, o.inner
+	}
+	p, _ := g.joined().(pathRelations)
+	q := o.joined()
+	if q == nil {
+		return nil, nil
+	}
+	return p, q
+}
+
+// joined returns a single Pattern which matches the same paths as the route,
+// or nil if its Patterns cannot be joined.
+func (g *groupPattern) joined() interface{} {
+	var p interface{} = g.inner
+	for i := len(g.prefixes) - 1; i >= 0; i-- {
+		pj, ok := g.prefixes[i].(pathJoiner)
+		if !ok {
+			return nil
+		}
+		if p = pj.JoinPath(p); p == nil {
+			return nil
+		}
+	}
+	return p
+}
+
+func samePatterns(a, b []Pattern) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		t := reflect.TypeOf(a[i])
+		if t != reflect.TypeOf(b[i]) || !t.Comparable() || a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// reported returns the Pattern that should be reported as having matched a
+// request (for instance, by middleware.Pattern) when the given Pattern does.
+func reported(p Pattern) Pattern {
+	if g, ok := p.(*groupPattern); ok {
+		return g.inner
+	}
+	return p
+}
@@ -0,0 +1,161 @@
+package goji
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"goji.io/internal"
+	"goji.io/pat"
+)
+
+func TestGroup(t *testing.T) {
+	t.Parallel()
+
+	m := NewMux()
+	ch := make(chan string, 20)
+	m.Use(makeMiddleware(ch, "mux"))
+
+	inner := pat.Get("/users/:name")
+	api := m.Group(pat.New("/api/:version/*"))
+	api.HandleFunc(inner, func(w http.ResponseWriter, r *http.Request) {
+		if p := r.Context().Value(internal.Pattern); p != inner {
+			t.Errorf("pattern: expected %v, got %v", inner, p)
+		}
+		ch <- pat.Param(r, "version") + " " + pat.Param(r, "name")
+	}).Use(makeMiddleware(ch, "route"))
+	m.HandleFunc(pat.New("/*"), func(w http.ResponseWriter, r *http.Request) {
+		ch <- "fallback"
+	})
+
+	// Group middleware applies to routes registered before Use.
+	api.Use(makeMiddleware(ch, "group"))
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/api/v1/users/carl", nil)
+	m.ServeHTTP(w, r)
+	expectSequence(t, ch, "before mux", "before group", "before route",
+		"v1 carl", "after route", "after group", "after mux")
+
+	r, _ = http.NewRequest("GET", "/api/v1/nope", nil)
+	m.ServeHTTP(w, r)
+	expectSequence(t, ch, "before mux", "fallback", "after mux")
+
+	r, _ = http.NewRequest("POST", "/api/v1/users/carl", nil)
+	m.ServeHTTP(w, r)
+	expectSequence(t, ch, "before mux", "fallback", "after mux")
+}
+
+func TestGroupNested(t *testing.T) {
+	t.Parallel()
+
+	m := NewMux()
+	ch := make(chan string, 20)
+	outer := m.Group(pat.New("/:a/*"))
+	outer.Use(makeMiddleware(ch, "outer"))
+	nested := outer.Group(pat.New("/:b/*"))
+	nested.HandleFunc(pat.New("/:c"), func(w http.ResponseWriter, r *http.Request) {
+		ch <- pat.Param(r, "a") + pat.Param(r, "b") + pat.Param(r, "c")
+	})
+	nested.Use(makeMiddleware(ch, "nested"))
+	outer.HandleFunc(pat.New("/x"), func(w http.ResponseWriter, r *http.Request) {
+		ch <- "x"
+	})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/1/2/3", nil)
+	m.ServeHTTP(w, r)
+	expectSequence(t, ch, "before outer", "before nested", "123", "after nested", "after outer")
+
+	r, _ = http.NewRequest("GET", "/1/x", nil)
+	m.ServeHTTP(w, r)
+	expectSequence(t, ch, "before outer", "x", "after outer")
+
+	routes := m.Routes()
+	if len(routes) != 2 {
+		t.Fatalf("expected 2 routes, got %d", len(routes))
+	}
+	if s := routes[0].Pattern.(*groupPattern).String(); s != "/:a/:b/:c" {
+		t.Errorf("unexpected pattern %q", s)
+	}
+}
+
+func TestGroupPattern(t *testing.T) {
+	t.Parallel()
+
+	gp := &groupPattern{
+		prefixes: []Pattern{pat.New("/api/*"), pat.Post("/v1/*")},
+		inner:    pat.Get("/users"),
+	}
+	if methods := gp.HTTPMethods(); len(methods) != 0 {
+		t.Errorf("expected no methods, got %v", methods)
+	}
+	gp.inner = pat.New("/users")
+	if methods := gp.HTTPMethods(); len(methods) != 1 {
+		t.Errorf("expected POST, got %v", methods)
+	} else if _, ok := methods["POST"]; !ok {
+		t.Errorf("expected POST, got %v", methods)
+	}
+	if pp := gp.PathPrefix(); pp != "/api/" {
+		t.Errorf("path prefix: expected %q, got %q", "/api/", pp)
+	}
+	if p := reported(gp); p != gp.inner {
+		t.Errorf("reported: expected %v, got %v", gp.inner, p)
+	}
+}
+
+func TestGroupPathSegments(t *testing.T) {
+	t.Parallel()
+
+	gp := &groupPattern{
+		prefixes: []Pattern{pat.New("/api/*"), pat.New("/:version/*")},
+		inner:    pat.Get("/users/:name"),
+	}
+	expected := pat.New("/api/:version/users/:name").PathSegments()
+	if segs := gp.PathSegments(); !reflect.DeepEqual(segs, expected) {
+		t.Errorf("expected %v, got %v", expected, segs)
+	}
+
+	gp.prefixes = []Pattern{pat.New("/api")}
+	if segs := gp.PathSegments(); segs != nil {
+		t.Errorf("expected no segments for a prefix without a wildcard, got %v", segs)
+	}
+	gp.prefixes = []Pattern{pat.New("/api/*")}
+	gp.inner = boolPattern(true)
+	if segs := gp.PathSegments(); segs != nil {
+		t.Errorf("expected no segments for a Pattern without them, got %v", segs)
+	}
+}
+
+const benchmarkGroupRoutes = 200
+
+func benchmarkGroup(b *testing.B, m *Mux) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/api/v1/r150/42", nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.ServeHTTP(w, r)
+	}
+}
+
+func BenchmarkGroup(b *testing.B) {
+	m := NewMux()
+	api := m.Group(pat.New("/api/:version/*"))
+	for i := 0; i < benchmarkGroupRoutes; i++ {
+		api.Handle(pat.Get(fmt.Sprintf("/r%d/:id", i)), intHandler(i))
+	}
+	benchmarkGroup(b, m)
+}
+
+func BenchmarkGroupSubMux(b *testing.B) {
+	m := NewMux()
+	api := SubMux()
+	for i := 0; i < benchmarkGroupRoutes; i++ {
+		api.Handle(pat.Get(fmt.Sprintf("/r%d/:id", i)), intHandler(i))
+	}
+	m.Handle(pat.New("/api/:version/*"), api)
+	benchmarkGroup(b, m)
+}
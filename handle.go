@@ -21,17 +21,62 @@ algorithm:
 		}
 	}
 
+If no route matches, Goji will respond with a 404 Not Found. If however some
+route would have matched had the request used a different HTTP method, Goji
+instead responds with a 405 Method Not Allowed, listing those methods in the
+Allow header. Only methods that a Pattern advertises using the HTTPMethods
+optimization are considered; see the documentation for Pattern for more. Both
+responses can be replaced by a redirect to a canonical path; see Redirect.
+
 It is not safe to concurrently register routes from multiple goroutines, or to
-register routes concurrently with requests.
+register routes concurrently with requests, unless the Mux is in copy-on-write
+mode (see CopyOnWrite).
+
+Handle returns a Registration, which can be used to later remove the route or
+to change its Handler.
 */
-func (m *Mux) Handle(p Pattern, h http.Handler) {
-	m.router.add(p, h)
+func (m *Mux) Handle(p Pattern, h http.Handler) *Registration {
+	return m.register(nil, "", p, h)
 }
 
 /*
 HandleFunc adds a new route to the Mux. It is equivalent to calling Handle on a
 handler wrapped with http.HandlerFunc, and is provided only for convenience.
 */
-func (m *Mux) HandleFunc(p Pattern, h func(http.ResponseWriter, *http.Request)) {
-	m.Handle(p, http.HandlerFunc(h))
+func (m *Mux) HandleFunc(p Pattern, h func(http.ResponseWriter, *http.Request)) *Registration {
+	return m.Handle(p, http.HandlerFunc(h))
+}
+
+/*
+HandleNamed adds a new route to the Mux in the same manner as Handle, and
+additionally associates the given name with the route's Pattern. Named routes
+can be used to build links to the route without hard-coding paths; see URL for
+more.
+
+Names must be unique within a Mux: HandleNamed panics if the given name has
+already been used. Removing a named route (see Registration) frees its name.
+*/
+func (m *Mux) HandleNamed(name string, p Pattern, h http.Handler) *Registration {
+	return m.register(nil, name, p, h)
+}
+
+/*
+NotFound sets the http.Handler that the Mux dispatches to when no route matches
+a request. If no NotFound handler is set (or if it is set to nil),
+net/http.NotFound is used.
+
+The NotFound handler is called at the end of the Mux's middleware stack in the
+same manner as ordinary routes, so middleware observes unmatched requests as
+well. Requests for which Goji would instead respond with a 405 Method Not
+Allowed (see Handle) are not passed to the NotFound handler.
+
+Every Mux has its own NotFound handler, so a SubMux may handle unmatched
+requests differently from its parent. It is not safe to call NotFound
+concurrently with requests unless the Mux is in copy-on-write mode.
+*/
+func (m *Mux) NotFound(h http.Handler) {
+	m.update(func(m *Mux) {
+		m.notFound = h
+		m.buildChain()
+	})
 }
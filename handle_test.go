@@ -1,8 +1,12 @@
 package goji
 
 import (
+	"context"
 	"net/http"
+	"net/http/httptest"
 	"testing"
+
+	"goji.io/internal"
 )
 
 func TestHandle(t *testing.T) {
@@ -38,3 +42,105 @@ func TestHandleFunc(t *testing.T) {
 		t.Error("expected handler to be called")
 	}
 }
+
+func TestNotFound(t *testing.T) {
+	t.Parallel()
+
+	m := NewMux()
+	ch := make(chan string, 10)
+	m.Use(makeMiddleware(ch, "one"))
+	m.NotFound(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ch <- "not found"
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	m.Handle(boolPattern(false), intHandler(0))
+
+	w, r := wr()
+	m.ServeHTTP(w, r)
+	if w.Code != http.StatusTeapot {
+		t.Errorf("status: expected %d, got %d", http.StatusTeapot, w.Code)
+	}
+	expectSequence(t, ch, "before one", "not found", "after one")
+
+	m.NotFound(nil)
+	w, r = wr()
+	m.ServeHTTP(w, r)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status: expected %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestRegistration(t *testing.T) {
+	t.Parallel()
+
+	m := NewMux()
+	a := m.Handle(boolPattern(true), intHandler(0))
+	m.Handle(boolPattern(true), intHandler(1))
+
+	route := func() interface{} {
+		_, r := wr()
+		r = r.WithContext(context.WithValue(r.Context(), internal.Path, "/"))
+		return m.router.route(r).Context().Value(internal.Handler)
+	}
+
+	if h := route(); h != intHandler(0) {
+		t.Errorf("expected handler 0, got %v", h)
+	}
+	a.SetHandler(intHandler(2))
+	if h := route(); h != intHandler(2) {
+		t.Errorf("expected handler 2, got %v", h)
+	}
+	a.Remove()
+	if h := route(); h != intHandler(1) {
+		t.Errorf("expected handler 1, got %v", h)
+	}
+	a.Remove()
+	a.SetHandler(intHandler(3))
+	if n := len(m.Routes()); n != 1 {
+		t.Errorf("expected 1 route, got %d", n)
+	}
+
+	named := m.HandleNamed("home", revPattern{prefix: "/"}, intHandler(4))
+	named.Remove()
+	if _, err := m.URL("home", nil); err == nil {
+		t.Error("expected removed route to lose its name")
+	}
+	m.HandleNamed("home", revPattern{prefix: "/"}, intHandler(5))
+}
+
+func TestRouteMiddleware(t *testing.T) {
+	t.Parallel()
+
+	m := NewMux()
+	ch := make(chan string, 10)
+	m.Use(makeMiddleware(ch, "mux"))
+	reg := m.HandleFunc(testPattern{mark: new(int), prefix: "/a"}, func(w http.ResponseWriter, r *http.Request) {
+		ch <- "a"
+	})
+	reg.Use(makeMiddleware(ch, "one")).Use(makeMiddleware(ch, "two"))
+	m.HandleFunc(testPattern{mark: new(int), prefix: "/b"}, func(w http.ResponseWriter, r *http.Request) {
+		ch <- "b"
+	})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/a", nil)
+	m.ServeHTTP(w, r)
+	expectSequence(t, ch, "before mux", "before one", "before two", "a", "after two", "after one", "after mux")
+
+	r, _ = http.NewRequest("GET", "/b", nil)
+	m.ServeHTTP(w, r)
+	expectSequence(t, ch, "before mux", "b", "after mux")
+
+	reg.SetHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ch <- "c"
+	}))
+	r, _ = http.NewRequest("GET", "/a", nil)
+	m.ServeHTTP(w, r)
+	expectSequence(t, ch, "before mux", "before one", "before two", "c", "after two", "after one", "after mux")
+
+	if h := m.Routes()[0].Handler; h == nil {
+		t.Error("expected route to have a handler")
+	} else if _, ok := h.(http.HandlerFunc); !ok {
+		t.Errorf("expected the route's own handler, got %T", h)
+	}
+}
@@ -14,4 +14,11 @@ var (
 	// mached (and will therefore dispatch to at the end of the middleware
 	// stack).
 	Handler interface{} = ContextKey(2)
+	// Trace is the context key used to store the *goji.Trace that records
+	// routing decisions for the request, if it is being traced.
+	Trace interface{} = ContextKey(3)
+	// Patterns is the context key used to look up the chain of Patterns
+	// matched by every Mux the request has been routed through. It is
+	// computed on demand rather than stored.
+	Patterns interface{} = ContextKey(4)
 )
@@ -0,0 +1,18 @@
+package internal
+
+import "strings"
+
+// Template joins the string forms of a chain of nested Patterns, outermost
+// first, into a single route template. Each Pattern ending in a trailing
+// wildcard ("/*") is assumed to hand the rest of the path to the next one, so
+// "/users/*" followed by "/:name/photos" becomes "/users/:name/photos".
+func Template(parts []string) string {
+	var t string
+	for _, part := range parts {
+		if strings.HasSuffix(t, "/*") {
+			t = t[:len(t)-1]
+		}
+		t = strings.TrimSuffix(t, "/") + part
+	}
+	return t
+}
@@ -55,21 +55,76 @@ Patterns, or to view or modify the http.Handler that will be routed to.
 Middleware authors should read the documentation for the "middleware" subpackage
 for more information about how this is done.
 
+Middleware added with Use applies to every request the Mux serves. To apply
+middleware to a single route, see Registration.Use. To run middleware before
+routing is performed, see UsePre.
+
 The http.Handler returned by the given middleware must be safe for concurrent
 use by multiple goroutines. It is not safe to concurrently register middleware
-from multiple goroutines, or to register middleware concurrently with requests.
+from multiple goroutines, or to register middleware concurrently with requests,
+unless the Mux is in copy-on-write mode (see CopyOnWrite).
 */
 func (m *Mux) Use(middleware func(http.Handler) http.Handler) {
-	m.middleware = append(m.middleware, middleware)
-	m.buildChain()
+	m.update(func(m *Mux) {
+		m.middleware = append(m.middleware, middleware)
+		m.buildChain()
+	})
+}
+
+/*
+UsePre appends a middleware to the Mux's pre-routing middleware stack.
+
+Pre-routing middleware behave like the middleware added with Use (and are called
+in the same order), except that they run before the Mux routes the request
+rather than after it. Since route selection happens only once the pre-routing
+stack calls its inner http.Handler, pre-routing middleware can change which route
+a request is routed to: common examples include HTTP method overrides, path
+rewriting, and host name normalization. Any changes made to the request that is
+passed to the inner http.Handler are visible to Patterns.
+
+Patterns match paths using the path stored in the request's context, not the
+request's URL. Pre-routing middleware that rewrite the path should do so using
+pattern.SetPath (and may read the current path with pattern.Path). Pre-routing
+middleware run before routing information has been placed in the context, so
+functions like middleware.Pattern and middleware.Handler return nil.
+
+The http.Handler returned by the given middleware must be safe for concurrent
+use by multiple goroutines. It is not safe to concurrently register middleware
+from multiple goroutines, or to register middleware concurrently with requests,
+unless the Mux is in copy-on-write mode (see CopyOnWrite). In copy-on-write mode,
+pre-routing middleware functions are called again after every configuration
+change.
+*/
+func (m *Mux) UsePre(middleware func(http.Handler) http.Handler) {
+	m.update(func(m *Mux) {
+		m.pre = append(m.pre, middleware)
+		m.buildPre()
+	})
 }
 
 // Pre-compile a http.Handler for us to use during dispatch. Yes, this means
 // that adding middleware is quadratic, but it (a) happens during configuration
 // time, not at "runtime", and (b) n should ~always be small.
 func (m *Mux) buildChain() {
-	m.handler = dispatch{}
+	m.handler = dispatch{
+		notFound:      m.notFound,
+		autoOptions:   m.autoOptions,
+		profileLabels: m.profileLabels,
+	}
 	for i := len(m.middleware) - 1; i >= 0; i-- {
 		m.handler = m.middleware[i](m.handler)
 	}
 }
+
+// buildPre pre-compiles the pre-routing middleware stack. Since the stack ends
+// in a method value bound to this Mux, it must be rebuilt for every copy.
+func (m *Mux) buildPre() {
+	m.preHandler = nil
+	if len(m.pre) == 0 {
+		return
+	}
+	m.preHandler = http.HandlerFunc(m.serve)
+	for i := len(m.pre) - 1; i >= 0; i-- {
+		m.preHandler = m.pre[i](m.preHandler)
+	}
+}
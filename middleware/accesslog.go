@@ -0,0 +1,94 @@
+// +build go1.21
+
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"sort"
+	"time"
+
+	"goji.io/pattern"
+)
+
+/*
+AccessLog returns a middleware that logs every request it serves to the given
+logger (or to slog.Default, if the logger is nil) once the request has been
+served. Since it logs how requests were routed, it should be installed as
+described in Routing Information in the package documentation.
+
+Requests routed to a handler are logged with the message "request", and
+requests that were not routed to any handler (and so receive a 404 or 405
+response) with the message "unmatched request". Each entry has the following
+attributes:
+
+	method   the request's method
+	path     the request's escaped path
+	route    the matched route template, as returned by Template
+	vars     a group of the variables bound by the matched Patterns
+	status   the response's status code
+	size     the number of bytes in the response body
+	latency  the time taken to serve the request
+
+The route and vars attributes are omitted for unmatched requests. A request
+routed to a SubMux counts as matched by the parent Mux, even if the SubMux does
+not route it; install AccessLog on the SubMux for more precise logs.
+
+AccessLog requires Go 1.21.
+*/
+func AccessLog(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rw := WrapResponseWriter(w)
+			h.ServeHTTP(rw, r)
+			latency := time.Since(start)
+
+			l := logger
+			if l == nil {
+				l = slog.Default()
+			}
+			status := rw.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			ctx := r.Context()
+			msg := "request"
+			attrs := make([]slog.Attr, 0, 7)
+			attrs = append(attrs,
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.EscapedPath()),
+			)
+			if Handler(ctx) == nil {
+				msg = "unmatched request"
+			} else {
+				attrs = append(attrs,
+					slog.String("route", Template(ctx)),
+					slog.Attr{Key: "vars", Value: slog.GroupValue(variables(r)...)},
+				)
+			}
+			attrs = append(attrs,
+				slog.Int("status", status),
+				slog.Int64("size", rw.BytesWritten()),
+				slog.Duration("latency", latency),
+			)
+			l.LogAttrs(ctx, slog.LevelInfo, msg, attrs...)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// variables returns the variables bound in the request's context, sorted by
+// name.
+func variables(r *http.Request) []slog.Attr {
+	vars, _ := r.Context().Value(pattern.AllVariables).(map[pattern.Variable]interface{})
+	attrs := make([]slog.Attr, 0, len(vars))
+	for name, value := range vars {
+		attrs = append(attrs, slog.Any(string(name), value))
+	}
+	sort.Slice(attrs, func(i, j int) bool {
+		return attrs[i].Key < attrs[j].Key
+	})
+	return attrs
+}
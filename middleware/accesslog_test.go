@@ -0,0 +1,71 @@
+// +build go1.21
+
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"goji.io"
+	"goji.io/pat"
+)
+
+func TestAccessLog(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	m := goji.NewMux()
+	m.Use(AccessLog(logger))
+	users := goji.SubMux()
+	m.Handle(pat.New("/users/:name/*"), users)
+	users.HandleFunc(pat.Get("/photos/:id"), func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("photo"))
+	})
+	m.HandleFunc(pat.Get("/ok"), func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		method, path string
+		entry        map[string]interface{}
+	}{
+		{"GET", "/users/carl/photos/1", map[string]interface{}{
+			"msg": "request", "method": "GET", "path": "/users/carl/photos/1",
+			"route": "/users/:name/*", "vars": map[string]interface{}{"name": "carl"},
+			"status": 201.0, "size": 5.0,
+		}},
+		{"GET", "/ok", map[string]interface{}{
+			"msg": "request", "method": "GET", "path": "/ok", "route": "/ok",
+			"status": 200.0, "size": 0.0,
+		}},
+		{"POST", "/a%2Fb", map[string]interface{}{
+			"msg": "unmatched request", "method": "POST", "path": "/a%2Fb",
+			"status": 404.0, "size": 19.0,
+		}},
+	}
+	for _, test := range tests {
+		buf.Reset()
+		r, _ := http.NewRequest(test.method, test.path, nil)
+		m.ServeHTTP(httptest.NewRecorder(), r)
+
+		var entry map[string]interface{}
+		if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+			t.Fatalf("[%s %s] bad log entry %q: %v", test.method, test.path, buf.String(), err)
+		}
+		if _, ok := entry["latency"].(float64); !ok {
+			t.Errorf("[%s %s] expected latency, got %v", test.method, test.path, entry["latency"])
+		}
+		delete(entry, "time")
+		delete(entry, "level")
+		delete(entry, "latency")
+		if !reflect.DeepEqual(entry, test.entry) {
+			t.Errorf("[%s %s] expected %v, got %v", test.method, test.path, test.entry, entry)
+		}
+	}
+}
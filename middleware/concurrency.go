@@ -0,0 +1,213 @@
+package middleware
+
+import (
+	"container/list"
+	"context"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+/*
+ConcurrencyLimit is a middleware that limits the number of requests each route
+serves concurrently, so that one slow route cannot tie up every goroutine (and
+every connection to its backends) at the expense of the others. Routes are
+identified by their route template (see Template); unmatched requests share the
+route UnmatchedRoute.
+
+Requests beyond a route's limit wait in a first-in, first-out queue for another
+request to finish, if the queue has room, and are otherwise shed with a 503
+Service Unavailable. Requests which wait longer than QueueTimeout, or whose
+context is done while waiting, are also shed.
+
+If TargetLatency is set, each route's limit adapts to the latency its requests
+observe: every request that takes longer than TargetLatency reduces the limit
+by a tenth (but never below MinLimit), and every other request raises it by
+about one per limit's worth of requests (but never above Limit). Routes whose
+latency grows under load are therefore given less concurrency, and so shed
+load sooner.
+
+The state of each route's limiter can be monitored using Stats. A
+ConcurrencyLimit must not be copied or modified after first use.
+*/
+type ConcurrencyLimit struct {
+	// Limit is the maximum number of requests each route may serve at once.
+	Limit int
+	// Queue is the maximum number of requests that may wait for each route.
+	// If it is zero, requests beyond the limit are shed immediately.
+	Queue int
+	// QueueTimeout is the longest a request may wait. If it is zero,
+	// requests wait until their context is done.
+	QueueTimeout time.Duration
+	// TargetLatency, if positive, enables adaptive limits.
+	TargetLatency time.Duration
+	// MinLimit is the lowest adaptive limit. It defaults to 1.
+	MinLimit int
+
+	once   sync.Once
+	mu     sync.Mutex
+	routes map[string]*limiter
+}
+
+/*
+ConcurrencyStats describes the state of the limiter for a single route.
+*/
+type ConcurrencyStats struct {
+	// Limit is the route's current limit, which only differs from the
+	// ConcurrencyLimit's Limit if the limit is adaptive.
+	Limit int
+	// InFlight is the number of requests being served.
+	InFlight int
+	// Queued is the number of requests waiting to be served.
+	Queued int
+	// Shed is the total number of requests that have been shed.
+	Shed uint64
+}
+
+// Middleware implements the ConcurrencyLimit middleware.
+func (c *ConcurrencyLimit) Middleware(h http.Handler) http.Handler {
+	c.once.Do(func() {
+		if c.Limit < 1 || c.Queue < 0 {
+			panic("goji: ConcurrencyLimit requires a positive Limit and a non-negative Queue")
+		}
+		if c.MinLimit < 1 {
+			c.MinLimit = 1
+		}
+		if c.MinLimit > c.Limit {
+			c.MinLimit = c.Limit
+		}
+		c.routes = make(map[string]*limiter)
+	})
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		route := UnmatchedRoute
+		if Handler(r.Context()) != nil {
+			route = Template(r.Context())
+		}
+		l := c.limiter(route)
+		if !l.acquire(r.Context(), c.Queue, c.QueueTimeout) {
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return
+		}
+		start := time.Now()
+		defer func() {
+			l.release(time.Since(start), c)
+		}()
+		h.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
+
+/*
+Stats returns the state of the limiter for every route that has served a
+request, keyed by route.
+*/
+func (c *ConcurrencyLimit) Stats() map[string]ConcurrencyStats {
+	c.mu.Lock()
+	routes := make(map[string]*limiter, len(c.routes))
+	for route, l := range c.routes {
+		routes[route] = l
+	}
+	c.mu.Unlock()
+
+	stats := make(map[string]ConcurrencyStats, len(routes))
+	for route, l := range routes {
+		stats[route] = l.stats()
+	}
+	return stats
+}
+
+func (c *ConcurrencyLimit) limiter(route string) *limiter {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	l, ok := c.routes[route]
+	if !ok {
+		l = &limiter{limit: float64(c.Limit)}
+		c.routes[route] = l
+	}
+	return l
+}
+
+// limiter limits the concurrency of a single route.
+type limiter struct {
+	mu       sync.Mutex
+	limit    float64
+	inFlight int
+	waiting  list.List // of chan struct{}, closed to admit the waiter
+	shed     uint64
+}
+
+// acquire admits a request, waiting if necessary, and reports whether it was
+// admitted. Requests that are not admitted are counted as shed.
+func (l *limiter) acquire(ctx context.Context, queue int, timeout time.Duration) bool {
+	l.mu.Lock()
+	if l.inFlight < int(l.limit) && l.waiting.Len() == 0 {
+		l.inFlight++
+		l.mu.Unlock()
+		return true
+	}
+	if l.waiting.Len() >= queue {
+		l.shed++
+		l.mu.Unlock()
+		return false
+	}
+	admit := make(chan struct{})
+	e := l.waiting.PushBack(admit)
+	l.mu.Unlock()
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		expired = t.C
+	}
+	select {
+	case <-admit:
+		return true
+	case <-expired:
+	case <-ctx.Done():
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	select {
+	case <-admit:
+		// We were admitted while giving up.
+		return true
+	default:
+	}
+	l.waiting.Remove(e)
+	l.shed++
+	return false
+}
+
+// release is called when an admitted request has been served, and admits as
+// many waiting requests as the limit allows.
+func (l *limiter) release(latency time.Duration, c *ConcurrencyLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if c.TargetLatency > 0 {
+		if latency > c.TargetLatency {
+			l.limit = math.Max(float64(c.MinLimit), l.limit*0.9)
+		} else {
+			l.limit = math.Min(float64(c.Limit), l.limit+1/l.limit)
+		}
+	}
+	l.inFlight--
+	for l.inFlight < int(l.limit) && l.waiting.Len() > 0 {
+		admit := l.waiting.Remove(l.waiting.Front()).(chan struct{})
+		l.inFlight++
+		close(admit)
+	}
+}
+
+func (l *limiter) stats() ConcurrencyStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return ConcurrencyStats{
+		Limit:    int(l.limit),
+		InFlight: l.inFlight,
+		Queued:   l.waiting.Len(),
+		Shed:     l.shed,
+	}
+}
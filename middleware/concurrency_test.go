@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"goji.io"
+	"goji.io/pat"
+)
+
+func TestConcurrencyLimit(t *testing.T) {
+	t.Parallel()
+
+	limit := &ConcurrencyLimit{Limit: 1, Queue: 1}
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	m := goji.NewMux()
+	m.Use(limit.Middleware)
+	m.HandleFunc(pat.Get("/slow/:id"), func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-unblock
+	})
+	m.HandleFunc(pat.Get("/fast"), func(w http.ResponseWriter, r *http.Request) {})
+
+	serve := func(path string) chan int {
+		ch := make(chan int, 1)
+		go func() {
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("GET", path, nil)
+			m.ServeHTTP(w, r)
+			ch <- w.Code
+		}()
+		return ch
+	}
+	waitQueued := func(n int) {
+		for limit.Stats()["/slow/:id"].Queued != n {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	first := serve("/slow/1")
+	<-started
+	second := serve("/slow/2")
+	waitQueued(1)
+	if code := <-serve("/slow/3"); code != http.StatusServiceUnavailable {
+		t.Errorf("expected request to be shed, got %d", code)
+	}
+	// Other routes are unaffected.
+	if code := <-serve("/fast"); code != http.StatusOK {
+		t.Errorf("expected other route to be served, got %d", code)
+	}
+
+	expected := ConcurrencyStats{Limit: 1, InFlight: 1, Queued: 1, Shed: 1}
+	if s := limit.Stats()["/slow/:id"]; s != expected {
+		t.Errorf("expected stats %+v, got %+v", expected, s)
+	}
+
+	unblock <- struct{}{}
+	if code := <-first; code != http.StatusOK {
+		t.Errorf("expected first request to be served, got %d", code)
+	}
+	<-started
+	unblock <- struct{}{}
+	if code := <-second; code != http.StatusOK {
+		t.Errorf("expected queued request to be served, got %d", code)
+	}
+
+	expected = ConcurrencyStats{Limit: 1, Shed: 1}
+	if s := limit.Stats()["/slow/:id"]; s != expected {
+		t.Errorf("expected stats %+v, got %+v", expected, s)
+	}
+}
+
+func TestConcurrencyLimitQueueTimeout(t *testing.T) {
+	t.Parallel()
+
+	limit := &ConcurrencyLimit{Limit: 1, Queue: 1, QueueTimeout: 10 * time.Millisecond}
+	unblock := make(chan struct{})
+	h := limit.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		r, _ := http.NewRequest("GET", "/", nil)
+		h.ServeHTTP(httptest.NewRecorder(), r)
+		close(done)
+	}()
+	for limit.Stats()[UnmatchedRoute].InFlight != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/", nil)
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected request to time out, got %d", w.Code)
+	}
+	close(unblock)
+	<-done
+
+	expected := ConcurrencyStats{Limit: 1, Shed: 1}
+	if s := limit.Stats()[UnmatchedRoute]; s != expected {
+		t.Errorf("expected stats %+v, got %+v", expected, s)
+	}
+}
+
+func TestConcurrencyLimitAdaptive(t *testing.T) {
+	t.Parallel()
+
+	c := &ConcurrencyLimit{Limit: 10, MinLimit: 2, TargetLatency: time.Second}
+	c.Middleware(http.NotFoundHandler())
+	l := c.limiter("/")
+
+	for i := 0; i < 100; i++ {
+		l.inFlight++
+		l.release(2*time.Second, c)
+	}
+	if s := l.stats(); s.Limit != 2 {
+		t.Errorf("expected limit to fall to the minimum, got %d", s.Limit)
+	}
+	for i := 0; i < 1000; i++ {
+		l.inFlight++
+		l.release(time.Millisecond, c)
+	}
+	if s := l.stats(); s.Limit != 10 {
+		t.Errorf("expected limit to recover to the maximum, got %d", s.Limit)
+	}
+}
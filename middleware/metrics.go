@@ -0,0 +1,213 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+UnmatchedRoute is the route label Metrics uses for requests that were not
+routed to any handler.
+*/
+const UnmatchedRoute = "unmatched"
+
+/*
+DefaultBuckets are the upper bounds, in seconds, of the latency histogram
+buckets used by NewMetrics if none are given.
+*/
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+/*
+Metrics collects per-route request metrics, and serves them in the Prometheus
+text exposition format.
+
+Metrics are labeled by route, rather than by path, so the number of time series
+is bounded by the number of routes. The route label is the request's route
+template (see Template), or UnmatchedRoute for requests that were not routed to
+any handler. The method label is the request's method, or "OTHER" for
+nonstandard methods. Metrics exposes the following metrics:
+
+	goji_requests_total             counter, by route, method, and code
+	goji_request_duration_seconds   histogram, by route and method
+	goji_requests_in_flight         gauge, by route
+
+Metrics are collected by the middleware returned by Middleware, and exposed by
+serving the Metrics itself as a http.Handler (typically on a route like
+pat.Get("/metrics")). Metrics is safe for concurrent use.
+*/
+type Metrics struct {
+	buckets []float64
+
+	mu        sync.Mutex
+	requests  map[requestKey]uint64
+	durations map[routeKey]*histogram
+	inFlight  map[string]int64
+}
+
+type routeKey struct {
+	route, method string
+}
+
+type requestKey struct {
+	routeKey
+	code int
+}
+
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+/*
+NewMetrics returns a new Metrics whose latency histograms use buckets with the
+given upper bounds, in seconds, which must be sorted in increasing order. If no
+bounds are given, DefaultBuckets is used.
+*/
+func NewMetrics(buckets ...float64) *Metrics {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	for i := 1; i < len(buckets); i++ {
+		if buckets[i] <= buckets[i-1] {
+			panic("goji: metric buckets must be in increasing order")
+		}
+	}
+	return &Metrics{
+		buckets:   append([]float64(nil), buckets...),
+		requests:  make(map[requestKey]uint64),
+		durations: make(map[routeKey]*histogram),
+		inFlight:  make(map[string]int64),
+	}
+}
+
+/*
+Middleware collects metrics for the requests served by the given http.Handler.
+Requests are labeled with the routes they matched, so Middleware should be
+installed as described in Routing Information in the package documentation.
+*/
+func (m *Metrics) Middleware(h http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		route := UnmatchedRoute
+		if Handler(r.Context()) != nil {
+			route = Template(r.Context())
+		}
+		key := routeKey{route: route, method: metricMethod(r.Method)}
+
+		m.mu.Lock()
+		m.inFlight[route]++
+		m.mu.Unlock()
+
+		start := time.Now()
+		rw := WrapResponseWriter(w)
+		defer func() {
+			elapsed := time.Since(start).Seconds()
+			code := rw.Status()
+			if code == 0 {
+				code = http.StatusOK
+			}
+
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			m.inFlight[route]--
+			m.requests[requestKey{key, code}]++
+			hist := m.durations[key]
+			if hist == nil {
+				hist = &histogram{counts: make([]uint64, len(m.buckets))}
+				m.durations[key] = hist
+			}
+			// Bucket counts are stored non-cumulatively, and summed
+			// when they're served.
+			i := sort.SearchFloat64s(m.buckets, elapsed)
+			if i < len(m.buckets) {
+				hist.counts[i]++
+			}
+			hist.count++
+			hist.sum += elapsed
+		}()
+		h.ServeHTTP(rw, r)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// ServeHTTP implements net/http.Handler, serving the collected metrics in the
+// Prometheus text exposition format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+	m.writeTo(&buf)
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+func (m *Metrics) writeTo(buf *bytes.Buffer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var lines []string
+	for k, n := range m.requests {
+		lines = append(lines, fmt.Sprintf("goji_requests_total{%s,code=\"%d\"} %d\n", k.labels(), k.code, n))
+	}
+	writeMetric(buf, "goji_requests_total", "counter", "Total number of HTTP requests served.", lines)
+
+	lines = lines[:0]
+	for k, hist := range m.durations {
+		labels := k.labels()
+		// Lines for a single route must stay in order when sorted, so
+		// they're written as a single string.
+		var hb bytes.Buffer
+		var cumulative uint64
+		for i, bound := range m.buckets {
+			cumulative += hist.counts[i]
+			fmt.Fprintf(&hb, "goji_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n", labels, formatFloat(bound), cumulative)
+		}
+		fmt.Fprintf(&hb, "goji_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, hist.count)
+		fmt.Fprintf(&hb, "goji_request_duration_seconds_sum{%s} %s\n", labels, formatFloat(hist.sum))
+		fmt.Fprintf(&hb, "goji_request_duration_seconds_count{%s} %d\n", labels, hist.count)
+		lines = append(lines, hb.String())
+	}
+	writeMetric(buf, "goji_request_duration_seconds", "histogram", "HTTP request latencies in seconds.", lines)
+
+	lines = lines[:0]
+	for route, n := range m.inFlight {
+		lines = append(lines, fmt.Sprintf("goji_requests_in_flight{route=\"%s\"} %d\n", escapeLabel(route), n))
+	}
+	writeMetric(buf, "goji_requests_in_flight", "gauge", "Number of HTTP requests currently being served.", lines)
+}
+
+func writeMetric(buf *bytes.Buffer, name, typ, help string, lines []string) {
+	fmt.Fprintf(buf, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+	sort.Strings(lines)
+	for _, line := range lines {
+		buf.WriteString(line)
+	}
+}
+
+func (k routeKey) labels() string {
+	return fmt.Sprintf("route=\"%s\",method=\"%s\"", escapeLabel(k.route), k.method)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// metricMethod bounds the number of distinct method labels by mapping
+// nonstandard methods to "OTHER".
+func metricMethod(method string) string {
+	switch method {
+	case "GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "CONNECT", "OPTIONS", "TRACE":
+		return method
+	}
+	return "OTHER"
+}
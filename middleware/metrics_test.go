@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"goji.io"
+	"goji.io/pat"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+
+	metrics := NewMetrics(1000, 2000)
+	m := goji.NewMux()
+	m.Use(metrics.Middleware)
+	m.HandleFunc(pat.Get("/users/:name"), func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hi"))
+	})
+	m.HandleFunc(pat.Get("/busy"), func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		// Requests are counted as in flight while being served.
+		rec := httptest.NewRecorder()
+		metrics.ServeHTTP(rec, r)
+		if !strings.Contains(rec.Body.String(), `goji_requests_in_flight{route="/busy"} 1`) {
+			t.Errorf("expected request to be in flight, got:\n%s", rec.Body.String())
+		}
+	})
+
+	for _, req := range []struct{ method, path string }{
+		{"GET", "/users/carl"},
+		{"GET", "/users/bob"},
+		{"BREW", "/users/carl"},
+		{"GET", "/nope/\"quoted\""},
+		{"GET", "/busy"},
+	} {
+		r, _ := http.NewRequest(req.method, req.path, nil)
+		m.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/metrics", nil)
+	metrics.ServeHTTP(w, r)
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("unexpected content type %q", ct)
+	}
+	body := w.Body.String()
+	for _, line := range []string{
+		"# TYPE goji_requests_total counter",
+		`goji_requests_total{route="/users/:name",method="GET",code="200"} 2`,
+		`goji_requests_total{route="unmatched",method="OTHER",code="405"} 1`,
+		`goji_requests_total{route="unmatched",method="GET",code="404"} 1`,
+		`goji_requests_total{route="/busy",method="GET",code="503"} 1`,
+		"# TYPE goji_request_duration_seconds histogram",
+		`goji_request_duration_seconds_bucket{route="/users/:name",method="GET",le="1000"} 2`,
+		`goji_request_duration_seconds_bucket{route="/users/:name",method="GET",le="2000"} 2`,
+		`goji_request_duration_seconds_bucket{route="/users/:name",method="GET",le="+Inf"} 2`,
+		`goji_request_duration_seconds_count{route="/users/:name",method="GET"} 2`,
+		"# TYPE goji_requests_in_flight gauge",
+		`goji_requests_in_flight{route="/busy"} 0`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("expected metrics to contain %q, got:\n%s", line, body)
+		}
+	}
+}
+
+func TestMetricsBuckets(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected unsorted buckets to panic")
+		}
+	}()
+	NewMetrics(1, 0.5)
+}
+
+func TestEscapeLabel(t *testing.T) {
+	t.Parallel()
+
+	if s := escapeLabel("a\\b\"c\nd"); s != `a\\b\"c\nd` {
+		t.Errorf("unexpected escape %q", s)
+	}
+}
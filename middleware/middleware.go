@@ -4,11 +4,24 @@ Package middleware contains utilities for Goji Middleware authors.
 Unless you are writing middleware for your application, you should avoid
 importing this package. Instead, use the abstractions provided by your
 middleware package.
+
+
+Routing Information
+
+The middleware in this package which report how requests were routed, such as
+Recover, should be passed to Mux.Use (or installed on a Group or a route), where
+they run after the Mux has routed the request. Middleware installed with
+Mux.UsePre, or wrapped around the Mux, sees no routing information.
+
+A Mux only sees its own routing decisions, so middleware installed on it reports
+a request routed to a SubMux with the parent Mux's Pattern (for instance,
+"/users/*"), even if the SubMux routes it further.
 */
 package middleware
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 
 	"goji.io"
@@ -27,6 +40,38 @@ func Pattern(ctx context.Context) goji.Pattern {
 	return p.(goji.Pattern)
 }
 
+/*
+Patterns returns the Patterns matched by every Mux the request has been routed
+through, outermost first, or nil if no pattern was matched. For instance, given
+a SubMux registered on "/users/*" with a route for "/:name/photos", a request
+for "/users/carl/photos" has the Patterns "/users/*" and "/:name/photos". The
+prefixes of any Groups the matched routes belong to are included as well.
+
+Patterns only reflects routing decisions made by Muxes: Patterns set with
+SetPattern are not included. The returned slice must not be modified.
+*/
+func Patterns(ctx context.Context) []goji.Pattern {
+	chain, _ := ctx.Value(internal.Patterns).([]goji.Pattern)
+	return chain
+}
+
+/*
+Template returns the full route template matched by the request, suitable for
+use in logs, metrics, and traces. It joins the string forms of the Patterns
+returned by Patterns, treating a trailing "/*" as a placeholder for the
+remainder of the template: for instance, "/users/*" followed by "/:name/photos"
+yields "/users/:name/photos". If no pattern was matched, Template returns the
+empty string.
+*/
+func Template(ctx context.Context) string {
+	chain := Patterns(ctx)
+	parts := make([]string, len(chain))
+	for i, p := range chain {
+		parts[i] = fmt.Sprint(p)
+	}
+	return internal.Template(parts)
+}
+
 /*
 SetPattern returns a new context in which the given Pattern is used as the most
 recently matched pattern.
@@ -40,8 +85,9 @@ Handler returns the handler corresponding to the most recently matched Pattern,
 or nil if no pattern was matched.
 
 The handler returned by this function is the one that will be dispatched to at
-the end of the middleware stack. If the returned Handler is nil, http.NotFound
-will be used instead.
+the end of the middleware stack. If the returned Handler is nil, Goji will
+respond with a 405 if a route would have matched the request under a different
+HTTP method, and will otherwise use the Mux's NotFound handler.
 */
 func Handler(ctx context.Context) http.Handler {
 	h := ctx.Value(internal.Handler)
@@ -58,3 +104,12 @@ matched and which consequently will be dispatched to.
 func SetHandler(ctx context.Context, h http.Handler) context.Context {
 	return context.WithValue(ctx, internal.Handler, h)
 }
+
+/*
+Trace returns the routing trace for the request, or nil if the request is not
+being traced. See goji.Mux.TraceRequests for more.
+*/
+func Trace(ctx context.Context) *goji.Trace {
+	t, _ := ctx.Value(internal.Trace).(*goji.Trace)
+	return t
+}
@@ -3,7 +3,11 @@ package middleware
 import (
 	"context"
 	"net/http"
+	"net/http/httptest"
 	"testing"
+
+	"goji.io"
+	"goji.io/pat"
 )
 
 type testPattern bool
@@ -46,3 +50,67 @@ func TestHandler(t *testing.T) {
 		t.Errorf("got handler=%v, expected nil", h2)
 	}
 }
+
+func TestTrace(t *testing.T) {
+	t.Parallel()
+
+	if tr := Trace(context.Background()); tr != nil {
+		t.Errorf("got trace=%v, expected nil", tr)
+	}
+
+	m := goji.NewMux()
+	m.TraceRequests(true)
+	var tr *goji.Trace
+	m.HandleFunc(testPattern(true), func(w http.ResponseWriter, r *http.Request) {
+		tr = Trace(r.Context())
+	})
+	r, _ := http.NewRequest("GET", "/", nil)
+	r.Header.Set(goji.TraceHeader, "1")
+	m.ServeHTTP(httptest.NewRecorder(), r)
+	if tr == nil || len(tr.Steps) != 1 || !tr.Steps[0].Matched {
+		t.Errorf("got trace=%v, expected one matching step", tr)
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	t.Parallel()
+
+	if ps := Patterns(context.Background()); ps != nil {
+		t.Errorf("got patterns=%v, expected nil", ps)
+	}
+	if tmpl := Template(context.Background()); tmpl != "" {
+		t.Errorf("got template=%q, expected empty", tmpl)
+	}
+
+	var tmpl string
+	var n int
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tmpl = Template(r.Context())
+		n = len(Patterns(r.Context()))
+	})
+
+	root := goji.NewMux()
+	users := goji.SubMux()
+	root.Handle(pat.New("/users/*"), users)
+	users.Handle(pat.Get("/:name/photos"), h)
+	users.Handle(pat.Get("/"), h)
+	api := root.Group(pat.New("/api/*")).Group(pat.New("/:version/*"))
+	api.Handle(pat.Get("/albums/:id"), h)
+
+	var tests = []struct {
+		path, tmpl string
+		n          int
+	}{
+		{"/users/carl/photos", "/users/:name/photos", 2},
+		{"/users/", "/users/", 2},
+		{"/api/v1/albums/1", "/api/:version/albums/:id", 3},
+	}
+	for _, test := range tests {
+		tmpl, n = "", 0
+		r, _ := http.NewRequest("GET", test.path, nil)
+		root.ServeHTTP(httptest.NewRecorder(), r)
+		if tmpl != test.tmpl || n != test.n {
+			t.Errorf("[%s] got template=%q (%d), expected %q (%d)", test.path, tmpl, n, test.tmpl, test.n)
+		}
+	}
+}
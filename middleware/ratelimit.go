@@ -0,0 +1,258 @@
+package middleware
+
+import (
+	"container/list"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"goji.io/pattern"
+)
+
+/*
+RateLimit is a middleware that limits the rate of requests using token buckets.
+Requests are grouped by key, and each key has its own bucket, which holds up to
+Burst tokens and is refilled at Rate tokens per second. Each request takes a
+token from its key's bucket, and requests that find it empty are rejected with a
+429 Too Many Requests and a Retry-After header.
+
+For instance, to allow 100 requests per second per tenant to a group of routes:
+
+	api := mux.Group(pat.New("/api/:tenant/*"))
+	limit := &middleware.RateLimit{
+		Rate:  100,
+		Burst: 100,
+		Key:   middleware.KeyByVariable("tenant"),
+	}
+	api.Use(limit.Middleware)
+
+Every response, whether or not it was rejected, includes RateLimit-Limit,
+RateLimit-Remaining, and RateLimit-Reset headers describing the request's
+bucket, as proposed by the IETF's RateLimit header fields draft.
+
+A RateLimit must not be copied or modified after first use.
+*/
+type RateLimit struct {
+	// Rate is the number of tokens added to each bucket per second.
+	Rate float64
+	// Burst is the capacity of each bucket, and so the number of requests
+	// that can be served in a burst.
+	Burst int
+	// Key returns the key of the bucket for a request. Requests for which
+	// it returns the empty string are not limited. If Key is nil, requests
+	// are keyed by KeyByIP.
+	Key KeyFunc
+	// Store holds the buckets. If Store is nil, a MemoryStore holding up to
+	// DefaultStoreSize buckets is used.
+	Store RateLimitStore
+
+	once sync.Once
+}
+
+/*
+KeyFunc returns the rate limiting key for a request. See RateLimit.
+*/
+type KeyFunc func(r *http.Request) string
+
+/*
+KeyByIP keys requests by the IP address of their client, as reported by the
+request's RemoteAddr. Since RemoteAddr is the address of the immediate client,
+applications behind a proxy should instead key requests by the address the
+proxy reports.
+*/
+func KeyByIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+/*
+KeyByRoute keys requests by their route template (see Template), so that every
+route has its own bucket which all clients share.
+*/
+func KeyByRoute(r *http.Request) string {
+	return Template(r.Context())
+}
+
+/*
+KeyByVariable returns a KeyFunc that keys requests by the value they bind to the
+given Pattern variable, for instance a tenant or user name. Requests that do not
+bind the variable are not limited.
+*/
+func KeyByVariable(name string) KeyFunc {
+	return func(r *http.Request) string {
+		v, _ := r.Context().Value(pattern.Variable(name)).(string)
+		return v
+	}
+}
+
+/*
+Keys returns a KeyFunc that combines the keys returned by each of the given
+KeyFuncs, so that, for instance, every client has its own bucket for every
+route. Requests for which any KeyFunc returns the empty string are not limited.
+*/
+func Keys(fns ...KeyFunc) KeyFunc {
+	return func(r *http.Request) string {
+		keys := make([]string, len(fns))
+		for i, fn := range fns {
+			if keys[i] = fn(r); keys[i] == "" {
+				return ""
+			}
+		}
+		return strings.Join(keys, "\x00")
+	}
+}
+
+/*
+RateLimitStore holds token buckets on behalf of RateLimit. Implementations must
+be safe for concurrent use. A shared implementation allows several servers to
+enforce a common limit.
+*/
+type RateLimitStore interface {
+	// Take takes a token, if there is one, from the bucket with the given
+	// key, first refilling it at rate tokens per second up to burst tokens.
+	// Buckets the store has never seen are full.
+	Take(key string, rate float64, burst int, now time.Time) Bucket
+}
+
+/*
+Bucket describes the state of a token bucket after a request has tried to take
+a token from it.
+*/
+type Bucket struct {
+	// Allowed reports whether the request got a token.
+	Allowed bool
+	// Remaining is the number of whole tokens left in the bucket.
+	Remaining int
+	// Reset is the time until the bucket will be full again.
+	Reset time.Duration
+	// RetryAfter is the time until the next token is available, if the
+	// request did not get one.
+	RetryAfter time.Duration
+}
+
+// Middleware implements the RateLimit middleware.
+func (rl *RateLimit) Middleware(h http.Handler) http.Handler {
+	rl.once.Do(func() {
+		if rl.Rate <= 0 || rl.Burst < 1 {
+			panic("goji: RateLimit requires a positive Rate and Burst")
+		}
+		if rl.Key == nil {
+			rl.Key = KeyByIP
+		}
+		if rl.Store == nil {
+			rl.Store = NewMemoryStore(DefaultStoreSize)
+		}
+	})
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		key := rl.Key(r)
+		if key == "" {
+			h.ServeHTTP(w, r)
+			return
+		}
+		b := rl.Store.Take(key, rl.Rate, rl.Burst, time.Now())
+		hdr := w.Header()
+		hdr.Set("RateLimit-Limit", strconv.Itoa(rl.Burst))
+		hdr.Set("RateLimit-Remaining", strconv.Itoa(b.Remaining))
+		hdr.Set("RateLimit-Reset", seconds(b.Reset))
+		if !b.Allowed {
+			hdr.Set("Retry-After", seconds(b.RetryAfter))
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+			return
+		}
+		h.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// seconds formats a duration as a whole number of seconds, rounding up.
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
+
+/*
+DefaultStoreSize is the number of buckets held by the MemoryStore RateLimit uses
+if it is not given a Store.
+*/
+const DefaultStoreSize = 10000
+
+/*
+MemoryStore is a RateLimitStore that holds buckets in memory. It holds a bounded
+number of buckets, evicting the least recently used bucket to make room for new
+ones. Since evicted buckets are full when they are next used, MemoryStores
+should be large enough to hold every bucket in active use.
+*/
+type MemoryStore struct {
+	size int
+
+	mu      sync.Mutex
+	buckets map[string]*list.Element
+	lru     list.List
+}
+
+type memoryBucket struct {
+	key    string
+	tokens float64
+	last   time.Time
+}
+
+/*
+NewMemoryStore returns a new MemoryStore holding at most size buckets.
+*/
+func NewMemoryStore(size int) *MemoryStore {
+	if size < 1 {
+		panic("goji: MemoryStore size must be positive")
+	}
+	return &MemoryStore{size: size, buckets: make(map[string]*list.Element)}
+}
+
+// Take implements RateLimitStore.
+func (s *MemoryStore) Take(key string, rate float64, burst int, now time.Time) Bucket {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var mb *memoryBucket
+	if e, ok := s.buckets[key]; ok {
+		s.lru.MoveToFront(e)
+		mb = e.Value.(*memoryBucket)
+	} else {
+		if s.lru.Len() >= s.size {
+			oldest := s.lru.Back()
+			s.lru.Remove(oldest)
+			delete(s.buckets, oldest.Value.(*memoryBucket).key)
+		}
+		mb = &memoryBucket{key: key, tokens: float64(burst), last: now}
+		s.buckets[key] = s.lru.PushFront(mb)
+	}
+	return mb.take(rate, burst, now)
+}
+
+func (mb *memoryBucket) take(rate float64, burst int, now time.Time) Bucket {
+	capacity := float64(burst)
+	if elapsed := now.Sub(mb.last); elapsed > 0 {
+		mb.tokens = math.Min(capacity, mb.tokens+elapsed.Seconds()*rate)
+		mb.last = now
+	}
+
+	var b Bucket
+	if mb.tokens >= 1 {
+		mb.tokens--
+		b.Allowed = true
+	} else {
+		b.RetryAfter = duration((1 - mb.tokens) / rate)
+	}
+	b.Remaining = int(mb.tokens)
+	b.Reset = duration((capacity - mb.tokens) / rate)
+	return b
+}
+
+// duration converts a number of seconds into a Duration.
+func duration(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"goji.io"
+	"goji.io/pat"
+)
+
+func TestRateLimit(t *testing.T) {
+	t.Parallel()
+
+	limit := &RateLimit{
+		Rate:  0.001,
+		Burst: 2,
+		Key:   Keys(KeyByRoute, KeyByVariable("tenant")),
+	}
+	m := goji.NewMux()
+	api := m.Group(pat.New("/api/:tenant/*"))
+	api.Use(limit.Middleware)
+	api.HandleFunc(pat.Get("/things"), func(w http.ResponseWriter, r *http.Request) {})
+	// Requests that don't bind :tenant are not limited.
+	m.HandleFunc(pat.Get("/free"), func(w http.ResponseWriter, r *http.Request) {}).
+		Use(limit.Middleware)
+
+	tests := []struct {
+		path      string
+		code      int
+		remaining string
+	}{
+		{"/api/a/things", 200, "1"},
+		{"/api/a/things", 200, "0"},
+		{"/api/a/things", 429, "0"},
+		{"/api/b/things", 200, "1"},
+		{"/free", 200, ""},
+	}
+	for i, test := range tests {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", test.path, nil)
+		m.ServeHTTP(w, r)
+		if w.Code != test.code {
+			t.Errorf("[%d %s] status: expected %d, got %d", i, test.path, test.code, w.Code)
+		}
+		if rem := w.Header().Get("RateLimit-Remaining"); rem != test.remaining {
+			t.Errorf("[%d %s] remaining: expected %q, got %q", i, test.path, test.remaining, rem)
+		}
+		if test.code == 429 {
+			if ra := w.Header().Get("Retry-After"); ra != "1000" {
+				t.Errorf("[%d %s] Retry-After: expected %q, got %q", i, test.path, "1000", ra)
+			}
+			if l := w.Header().Get("RateLimit-Limit"); l != "2" {
+				t.Errorf("[%d %s] limit: expected %q, got %q", i, test.path, "2", l)
+			}
+		}
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	t.Parallel()
+
+	s := NewMemoryStore(2)
+	now := time.Unix(0, 0)
+	take := func(key string, d time.Duration) Bucket {
+		return s.Take(key, 1, 2, now.Add(d))
+	}
+
+	if b := take("a", 0); !b.Allowed || b.Remaining != 1 || b.Reset != time.Second {
+		t.Errorf("unexpected bucket %+v", b)
+	}
+	take("a", 0)
+	if b := take("a", 0); b.Allowed || b.RetryAfter != time.Second || b.Reset != 2*time.Second {
+		t.Errorf("expected empty bucket, got %+v", b)
+	}
+	if b := take("a", 1500*time.Millisecond); !b.Allowed || b.Remaining != 0 {
+		t.Errorf("expected bucket to refill, got %+v", b)
+	}
+	if b := take("a", time.Hour); !b.Allowed || b.Remaining != 1 {
+		t.Errorf("expected bucket to refill to its capacity, got %+v", b)
+	}
+
+	// "b" and then "c" evict "a", which is full again when next used.
+	take("a", time.Hour)
+	take("b", time.Hour)
+	take("c", time.Hour)
+	if len(s.buckets) != 2 || s.lru.Len() != 2 {
+		t.Errorf("expected 2 buckets, got %d", len(s.buckets))
+	}
+	if b := take("a", time.Hour); !b.Allowed || b.Remaining != 1 {
+		t.Errorf("expected evicted bucket to be full, got %+v", b)
+	}
+}
+
+func TestKeyByIP(t *testing.T) {
+	t.Parallel()
+
+	r, _ := http.NewRequest("GET", "/", nil)
+	for addr, key := range map[string]string{
+		"1.2.3.4:5678": "1.2.3.4",
+		"[::1]:5678":   "::1",
+		"unix-socket":  "unix-socket",
+	} {
+		r.RemoteAddr = addr
+		if k := KeyByIP(r); k != key {
+			t.Errorf("[%s] expected %q, got %q", addr, key, k)
+		}
+	}
+}
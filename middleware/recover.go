@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"runtime/debug"
+
+	"goji.io"
+)
+
+/*
+Panic describes a panic recovered by Recover.
+*/
+type Panic struct {
+	// Value is the value passed to panic.
+	Value interface{}
+	// Stack is the stack trace of the goroutine that panicked, formatted
+	// as by runtime/debug.Stack.
+	Stack []byte
+	// Request is the request being served when the panic occurred.
+	Request *http.Request
+	// Pattern is the Pattern the request was routed by, as returned by
+	// Pattern, or nil if the request was not routed to a handler.
+	Pattern goji.Pattern
+}
+
+/*
+Recover returns a middleware that recovers from panics in the handlers it wraps,
+reports them by calling the given function, and responds with a 500 Internal
+Server Error. If the handler had already begun writing its response before
+panicking, Recover cannot change its status code, and so leaves the response as
+it is. If report is nil, panics are logged using the standard log package.
+
+Panics with the value http.ErrAbortHandler, which handlers use to abort a
+response, are neither reported nor recovered from: Recover panics again, so that
+the net/http server can abort the connection as requested.
+
+The Pattern in the report is only set if the request was routed before Recover
+was called (see Routing Information in the package documentation). Since the
+report function is itself called from a deferred function, it must not panic.
+*/
+func Recover(report func(p Panic)) func(http.Handler) http.Handler {
+	if report == nil {
+		report = logPanic
+	}
+	return func(h http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			rw := WrapResponseWriter(w)
+			stack := new(panicStack)
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+				if isAbort(v) {
+					panic(v)
+				}
+				p := Panic{Request: r, Pattern: Pattern(r.Context()), Value: v, Stack: stack.stack}
+				if p.Stack == nil {
+					p.Stack = debug.Stack()
+				}
+				report(p)
+				if rw.Status() == 0 {
+					http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				}
+			}()
+			h.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), panicStackKey, stack)))
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+func logPanic(p Panic) {
+	log.Printf("goji: panic serving %s %s (pattern %v): %v\n%s", p.Request.Method, p.Request.URL, p.Pattern, p.Value, p.Stack)
+}
+
+type panicStackKeyType struct{}
+
+var panicStackKey = panicStackKeyType{}
+
+// panicStack is where middleware which runs handlers in goroutines of their own
+// (see Timeout) records the stack of a handler that panicked, since by the time
+// Recover sees the panic, it has been raised again on a different goroutine.
+type panicStack struct {
+	stack []byte
+}
+
+// recordStack records the stack of a handler goroutine which panicked, for the
+// Recover (if any) which handles the panic. If the panic has already passed
+// through other goroutines, the first stack recorded is kept.
+func recordStack(ctx context.Context, stack []byte) {
+	if ps, ok := ctx.Value(panicStackKey).(*panicStack); ok && ps.stack == nil {
+		ps.stack = stack
+	}
+}
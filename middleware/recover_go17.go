@@ -0,0 +1,8 @@
+// +build !go1.8
+
+package middleware
+
+// http.ErrAbortHandler was introduced in Go 1.8.
+func isAbort(v interface{}) bool {
+	return false
+}
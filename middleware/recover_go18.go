@@ -0,0 +1,9 @@
+// +build go1.8
+
+package middleware
+
+import "net/http"
+
+func isAbort(v interface{}) bool {
+	return v == http.ErrAbortHandler
+}
@@ -0,0 +1,27 @@
+// +build go1.8
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRecoverAbort(t *testing.T) {
+	t.Parallel()
+
+	h := Recover(func(p Panic) {
+		t.Errorf("unexpected report %+v", p)
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	defer func() {
+		if v := recover(); v != http.ErrAbortHandler {
+			t.Errorf("expected to recover %v, got %v", http.ErrAbortHandler, v)
+		}
+	}()
+	r, _ := http.NewRequest("GET", "/", nil)
+	h.ServeHTTP(httptest.NewRecorder(), r)
+}
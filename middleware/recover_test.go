@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"goji.io"
+	"goji.io/pat"
+)
+
+func TestRecover(t *testing.T) {
+	t.Parallel()
+
+	var reports []Panic
+	m := goji.NewMux()
+	m.Use(Recover(func(p Panic) {
+		reports = append(reports, p)
+	}))
+	boom := pat.Get("/boom")
+	m.HandleFunc(boom, func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	m.HandleFunc(pat.Get("/late"), func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		panic("late")
+	})
+	m.HandleFunc(pat.Get("/ok"), func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/boom", nil)
+	m.ServeHTTP(w, r)
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status: expected %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+	if len(reports) != 1 {
+		t.Fatalf("expected 1 report, got %d", len(reports))
+	}
+	if p := reports[0]; p.Value != "boom" || p.Pattern != boom || p.Request.URL.Path != "/boom" {
+		t.Errorf("unexpected report %+v", p)
+	} else if !strings.Contains(string(p.Stack), "recover_test.go") {
+		t.Errorf("expected stack to include the handler, got %s", p.Stack)
+	}
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("GET", "/late", nil)
+	m.ServeHTTP(w, r)
+	if w.Code != http.StatusAccepted {
+		t.Errorf("status: expected %d, got %d", http.StatusAccepted, w.Code)
+	}
+	if len(reports) != 2 {
+		t.Errorf("expected 2 reports, got %d", len(reports))
+	}
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("GET", "/ok", nil)
+	m.ServeHTTP(w, r)
+	if w.Code != http.StatusOK || w.Body.String() != "ok" {
+		t.Errorf("expected 200 ok, got %d %q", w.Code, w.Body.String())
+	}
+	if len(reports) != 2 {
+		t.Errorf("expected 2 reports, got %d", len(reports))
+	}
+}
@@ -0,0 +1,251 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"sync"
+	"time"
+)
+
+/*
+Timeout is a middleware that gives the requests it serves a time budget. Since
+Goji routes can have their own middleware, Timeout is typically used to give
+individual routes budgets of their own:
+
+	mux.HandleFunc(pat.Get("/search"), search).
+		Use(middleware.Timeout{Budget: 200 * time.Millisecond}.Middleware)
+
+The budget is applied as a deadline on the request's context, which the handler
+should honor by abandoning its work once the context is done. If the handler
+has not returned by the deadline, Timeout responds on its behalf with the
+configured status code, after which any further writes by the handler fail with
+http.ErrHandlerTimeout. Unlike http.TimeoutHandler, Timeout does not buffer
+responses: the handler's writes are passed through as they are made. Handlers
+that have already started their response when the deadline passes therefore
+have it cut short, since its status can no longer be changed.
+
+The handler is run in its own goroutine, and so must not use the request after
+the deadline has passed in ways that are not safe for concurrent use. Panics in
+the handler before the deadline are raised again, with the same value, in the
+goroutine serving the request, so that middleware like Recover can handle them;
+Recover reports the stack of the handler's goroutine, not Timeout's. Panics
+after the deadline have nobody to handle them, and are logged using the
+standard log package. The http.ResponseWriter passed to the handler supports
+http.Flusher, but not http.Hijacker.
+*/
+type Timeout struct {
+	// Budget is the time within which requests must be served. If it is
+	// not positive, requests are only given a budget by Header.
+	Budget time.Duration
+	// Header, if not empty, names a request header in which upstream
+	// services can pass their own remaining budget, as an integer number
+	// of milliseconds. If the header's budget is shorter than Budget, it is
+	// used instead.
+	Header string
+	// Code is the status code of responses to requests that exceed their
+	// budget. It is 503 Service Unavailable if not set; 504 Gateway
+	// Timeout may be more appropriate for proxies.
+	Code int
+}
+
+// Middleware implements the Timeout middleware.
+func (t Timeout) Middleware(h http.Handler) http.Handler {
+	code := t.Code
+	if code == 0 {
+		code = http.StatusServiceUnavailable
+	}
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		budget, ok := t.budget(r)
+		if !ok {
+			h.ServeHTTP(w, r)
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), budget)
+		defer cancel()
+
+		tw := &timeoutWriter{ctx: ctx, w: w, h: make(http.Header)}
+		done := make(chan struct{})
+		panicked := make(chan handlerPanic, 1)
+		go func() {
+			defer func() {
+				p := handlerPanic{value: recover()}
+				if p.value != nil && !isAbort(p.value) {
+					p.stack = debug.Stack()
+				}
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				if p.value != nil {
+					if !tw.abandoned {
+						panicked <- p
+					} else if p.stack != nil {
+						log.Printf("goji: panic serving %s %s after its deadline: %v\n%s", r.Method, r.URL, p.value, p.stack)
+					}
+				}
+				// done is closed with tw.mu held, so that abandon
+				// either sees it or sets abandoned first.
+				close(done)
+			}()
+			h.ServeHTTP(tw, r.WithContext(ctx))
+		}()
+
+		select {
+		case <-done:
+		case <-ctx.Done():
+			if tw.abandon(done, ctx.Err() == context.DeadlineExceeded, code) {
+				return
+			}
+		}
+		select {
+		case p := <-panicked:
+			if p.stack != nil {
+				recordStack(r.Context(), p.stack)
+			}
+			panic(p.value)
+		default:
+		}
+		tw.finish(code)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// budget returns the budget for the request, and whether it has one.
+func (t Timeout) budget(r *http.Request) (time.Duration, bool) {
+	budget, ok := t.Budget, t.Budget > 0
+	if t.Header == "" {
+		return budget, ok
+	}
+	ms, err := strconv.ParseInt(r.Header.Get(t.Header), 10, 64)
+	if err != nil || ms < 0 {
+		return budget, ok
+	}
+	if d := time.Duration(ms) * time.Millisecond; !ok || d < budget {
+		return d, true
+	}
+	return budget, ok
+}
+
+// handlerPanic carries a panic from the handler's goroutine to the goroutine
+// serving the request, along with the handler goroutine's stack.
+type handlerPanic struct {
+	value interface{}
+	stack []byte
+}
+
+// timeoutWriter passes writes through to w until ctx is done. The handler gets
+// its own header map, which is copied to w's when the response is started, so
+// that the handler never touches w's after the timeout.
+type timeoutWriter struct {
+	ctx context.Context
+	w   http.ResponseWriter
+	h   http.Header
+
+	mu        sync.Mutex
+	started   bool
+	abandoned bool
+	// refused is set once the handler has tried to write after the
+	// deadline.
+	refused bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.h
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.done() {
+		tw.refused = true
+		return
+	}
+	tw.writeHeader(code)
+}
+
+// writeHeader must be called with tw.mu held.
+func (tw *timeoutWriter) writeHeader(code int) {
+	if tw.started {
+		return
+	}
+	if code >= 200 || code == http.StatusSwitchingProtocols {
+		tw.started = true
+	}
+	dst := tw.w.Header()
+	for k, v := range tw.h {
+		dst[k] = append([]string(nil), v...)
+	}
+	tw.w.WriteHeader(code)
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.done() {
+		tw.refused = true
+		return 0, http.ErrHandlerTimeout
+	}
+	tw.writeHeader(http.StatusOK)
+	return tw.w.Write(b)
+}
+
+func (tw *timeoutWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.done() {
+		tw.refused = true
+		return
+	}
+	if f, ok := tw.w.(http.Flusher); ok {
+		tw.writeHeader(http.StatusOK)
+		f.Flush()
+	}
+}
+
+// done reports whether the handler's writes should no longer be passed
+// through: once the deadline has passed, or the middleware has returned (and
+// canceled ctx). It must be called with tw.mu held.
+func (tw *timeoutWriter) done() bool {
+	return tw.ctx.Err() != nil
+}
+
+// abandon is called once ctx is done, and reports whether the handler is still
+// running, in which case the middleware stops waiting for it. If the deadline
+// was exceeded and the response has not yet been started, abandon responds with
+// the given status code. Otherwise, the request was canceled, and there is
+// nobody to respond to.
+func (tw *timeoutWriter) abandon(done <-chan struct{}, exceeded bool, code int) bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	select {
+	case <-done:
+		return false
+	default:
+	}
+	tw.abandoned = true
+	if exceeded && !tw.started {
+		http.Error(tw.w, http.StatusText(code), code)
+	}
+	return true
+}
+
+// finish is called once the handler has returned. If the handler did not start
+// its response, finish copies its headers to w, so that the response net/http
+// starts on its behalf includes them, or, if the handler's writes were refused
+// because it overran the deadline, responds with the given status code.
+func (tw *timeoutWriter) finish(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.started {
+		return
+	}
+	if tw.refused && tw.ctx.Err() == context.DeadlineExceeded {
+		http.Error(tw.w, http.StatusText(code), code)
+		return
+	}
+	dst := tw.w.Header()
+	for k, v := range tw.h {
+		dst[k] = append([]string(nil), v...)
+	}
+}
@@ -0,0 +1,202 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"goji.io"
+	"goji.io/pat"
+)
+
+func TestTimeout(t *testing.T) {
+	t.Parallel()
+
+	errs := make(chan error, 1)
+	m := goji.NewMux()
+	m.HandleFunc(pat.Get("/slow"), func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		w.Header().Set("X-Slow", "1")
+		_, err := w.Write([]byte("too late"))
+		errs <- err
+	}).Use(Timeout{Budget: 10 * time.Millisecond}.Middleware)
+	m.HandleFunc(pat.Get("/fast"), func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); !ok {
+			t.Error("expected request to have a deadline")
+		}
+		w.Header().Set("X-Fast", "1")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("fast"))
+	}).Use(Timeout{Budget: time.Minute, Code: http.StatusGatewayTimeout}.Middleware)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/slow", nil)
+	m.ServeHTTP(w, r)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status: expected %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+	if err := <-errs; err != http.ErrHandlerTimeout {
+		t.Errorf("expected write to fail with %v, got %v", http.ErrHandlerTimeout, err)
+	}
+	if w.Header().Get("X-Slow") != "" {
+		t.Error("expected handler's headers to be discarded")
+	}
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("GET", "/fast", nil)
+	m.ServeHTTP(w, r)
+	if w.Code != http.StatusCreated || w.Body.String() != "fast" || w.Header().Get("X-Fast") != "1" {
+		t.Errorf("expected fast response, got %d %q %v", w.Code, w.Body.String(), w.Header())
+	}
+}
+
+func TestTimeoutHeadersOnly(t *testing.T) {
+	t.Parallel()
+
+	h := Timeout{Budget: time.Minute}.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Foo", "bar")
+		w.Header().Add("Set-Cookie", "a=1")
+	}))
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/", nil)
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusOK || w.Header().Get("X-Foo") != "bar" || w.Header().Get("Set-Cookie") != "a=1" {
+		t.Errorf("expected handler's headers, got %d %v", w.Code, w.Header())
+	}
+}
+
+func TestTimeoutFinishedAtDeadline(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	<-ctx.Done()
+	w := httptest.NewRecorder()
+	tw := &timeoutWriter{ctx: ctx, w: w, h: make(http.Header)}
+	tw.h.Set("X-Foo", "bar")
+
+	// The handler returned (without writing) just as the deadline passed.
+	done := make(chan struct{})
+	close(done)
+	if tw.abandon(done, true, http.StatusServiceUnavailable) {
+		t.Fatal("expected finished handler not to be abandoned")
+	}
+	tw.finish(http.StatusServiceUnavailable)
+	if w.Code != http.StatusOK || w.Header().Get("X-Foo") != "bar" {
+		t.Errorf("expected handler's response, got %d %v", w.Code, w.Header())
+	}
+
+	// The handler tried to write after the deadline, and then returned.
+	w = httptest.NewRecorder()
+	tw = &timeoutWriter{ctx: ctx, w: w, h: make(http.Header)}
+	if _, err := tw.Write([]byte("late")); err != http.ErrHandlerTimeout {
+		t.Errorf("expected %v, got %v", http.ErrHandlerTimeout, err)
+	}
+	tw.finish(http.StatusServiceUnavailable)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status: expected %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+}
+
+func TestTimeoutHeader(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		budget time.Duration
+		header string
+		want   time.Duration
+		ok     bool
+	}{
+		{time.Second, "", time.Second, true},
+		{time.Second, "100", 100 * time.Millisecond, true},
+		{time.Second, "5000", time.Second, true},
+		{time.Second, "bogus", time.Second, true},
+		{time.Second, "-1", time.Second, true},
+		{0, "", 0, false},
+		{0, "250", 250 * time.Millisecond, true},
+	}
+	for _, test := range tests {
+		to := Timeout{Budget: test.budget, Header: "X-Request-Budget"}
+		r, _ := http.NewRequest("GET", "/", nil)
+		if test.header != "" {
+			r.Header.Set("X-Request-Budget", test.header)
+		}
+		if d, ok := to.budget(r); d != test.want || ok != test.ok {
+			t.Errorf("[%v %q] expected %v %v, got %v %v", test.budget, test.header, test.want, test.ok, d, ok)
+		}
+	}
+}
+
+func TestTimeoutPanic(t *testing.T) {
+	t.Parallel()
+
+	var reported interface{}
+	var stack string
+	m := goji.NewMux()
+	m.Use(Recover(func(p Panic) {
+		reported, stack = p.Value, string(p.Stack)
+	}))
+	m.HandleFunc(pat.Get("/"), func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}).Use(Timeout{Budget: time.Minute}.Middleware)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/", nil)
+	m.ServeHTTP(w, r)
+	if reported != "boom" || w.Code != http.StatusInternalServerError {
+		t.Errorf("expected panic to be recovered, got %v (status %d)", reported, w.Code)
+	}
+	if !strings.Contains(stack, "TestTimeoutPanic.func") {
+		t.Errorf("expected the handler's stack, got %s", stack)
+	}
+
+	// Other recovery code sees the handler's own panic value.
+	errBoom := errors.New("boom")
+	h := Timeout{Budget: time.Minute}.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(errBoom)
+	}))
+	defer func() {
+		if v := recover(); v != errBoom {
+			t.Errorf("expected %v to be raised again, got %#v", errBoom, v)
+		}
+	}()
+	h.ServeHTTP(httptest.NewRecorder(), r)
+}
+
+// logWriter passes each line logged to a channel.
+type logWriter chan string
+
+func (w logWriter) Write(b []byte) (int, error) {
+	w <- string(b)
+	return len(b), nil
+}
+
+// TestTimeoutLatePanic is not parallel, since it captures the standard logger.
+func TestTimeoutLatePanic(t *testing.T) {
+	logged := make(logWriter, 1)
+	log.SetOutput(logged)
+	defer log.SetOutput(os.Stderr)
+
+	h := Timeout{Budget: time.Millisecond}.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		time.Sleep(10 * time.Millisecond)
+		panic("late")
+	}))
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/", nil)
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status: expected %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+
+	out := <-logged
+	if !strings.Contains(out, "after its deadline: late") || !strings.Contains(out, "TestTimeoutLatePanic.func") {
+		t.Errorf("expected panic to be logged with its stack, got %q", out)
+	}
+}
@@ -0,0 +1,290 @@
+package middleware
+
+import (
+	"context"
+	crand "crypto/rand"
+	"encoding/hex"
+	"fmt"
+	mrand "math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"goji.io/pattern"
+)
+
+/*
+TraceID is the identifier of a distributed trace, as defined by the W3C Trace
+Context specification.
+*/
+type TraceID [16]byte
+
+// String returns the ID in lowercase hex.
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+/*
+SpanID is the identifier of a span within a distributed trace, as defined by the
+W3C Trace Context specification.
+*/
+type SpanID [8]byte
+
+// String returns the ID in lowercase hex.
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+/*
+SpanContext is the part of a span which is propagated between services in the
+traceparent and tracestate headers.
+*/
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	// Flags holds the trace flags. The only flag currently defined is
+	// "sampled" (0x01).
+	Flags byte
+	// State is the vendor-specific tracestate header, which is propagated
+	// unchanged.
+	State string
+}
+
+// flagSampled is the "sampled" trace flag.
+const flagSampled = 0x01
+
+/*
+Traceparent formats the span context as a version 00 traceparent header.
+*/
+func (sc SpanContext) Traceparent() string {
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + hex.EncodeToString([]byte{sc.Flags})
+}
+
+/*
+Span is a single timed operation within a distributed trace: for Tracing, the
+serving of a single request.
+*/
+type Span struct {
+	// Name is the span's name. See Tracing.
+	Name string
+	// Context is the span's own SpanContext.
+	Context SpanContext
+	// Parent is the ID of the span's parent, which is usually in another
+	// service, or the zero SpanID if the span began a new trace.
+	Parent SpanID
+	// Start and End are the times at which the span began and ended.
+	Start, End time.Time
+	// Attributes describe the operation the span represents.
+	Attributes map[string]string
+}
+
+/*
+SpanExporter receives spans once they have ended. Implementations must be safe
+for concurrent use.
+*/
+type SpanExporter interface {
+	ExportSpan(s Span)
+}
+
+/*
+MemoryExporter is a SpanExporter which stores spans in memory, for use in tests.
+The zero value is ready to use.
+*/
+type MemoryExporter struct {
+	mu    sync.Mutex
+	spans []Span
+}
+
+// ExportSpan implements SpanExporter.
+func (e *MemoryExporter) ExportSpan(s Span) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, s)
+}
+
+/*
+Spans returns the spans exported so far, in the order in which they ended.
+*/
+func (e *MemoryExporter) Spans() []Span {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]Span(nil), e.spans...)
+}
+
+/*
+Reset discards all the spans exported so far.
+*/
+func (e *MemoryExporter) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = nil
+}
+
+type spanKeyType struct{}
+
+var spanKey = spanKeyType{}
+
+/*
+SpanFromContext returns the SpanContext of the span started by Tracing for the
+request, and whether there is one. Handlers making requests to other services
+should propagate it using InjectSpan.
+*/
+func SpanFromContext(ctx context.Context) (SpanContext, bool) {
+	s, ok := ctx.Value(spanKey).(*Span)
+	if !ok {
+		return SpanContext{}, false
+	}
+	return s.Context, true
+}
+
+/*
+InjectSpan sets the traceparent and tracestate headers for an outgoing request
+to propagate the span in the given context, if there is one.
+*/
+func InjectSpan(ctx context.Context, h http.Header) {
+	sc, ok := SpanFromContext(ctx)
+	if !ok {
+		return
+	}
+	h.Set("Traceparent", sc.Traceparent())
+	if sc.State != "" {
+		h.Set("Tracestate", sc.State)
+	} else {
+		h.Del("Tracestate")
+	}
+}
+
+/*
+Tracing returns a middleware that starts a span for every request it serves, and
+passes it to the given exporter once the request has been served, if it is
+sampled.
+
+If the request has a valid traceparent header, the span continues the trace it
+names, keeping its sampled flag and tracestate header. Otherwise, the span
+begins a new, sampled, trace. Either way, the span's context is stored in the
+request's context, from which it can be retrieved using SpanFromContext and
+propagated using InjectSpan. Spans in traces which the caller chose not to
+sample are propagated in this way, but not exported.
+
+The span is named after the request's method and route template (for instance,
+"GET /users/:name"; see Template), or after its method alone if it was not
+routed to any handler. Its attributes are:
+
+	http.method        the request's method
+	http.route         the route template, if the request was routed
+	http.status_code   the response's status code
+	goji.var.NAME      the value bound to each Pattern variable NAME
+
+Spans are named after the routing decisions of the Mux on which Tracing is
+installed; see Routing Information in the package documentation.
+*/
+func Tracing(exporter SpanExporter) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			s := &Span{Start: time.Now(), Attributes: make(map[string]string)}
+			if parent, ok := parseTraceparent(r.Header.Get("Traceparent")); ok {
+				s.Context.TraceID = parent.TraceID
+				s.Context.Flags = parent.Flags
+				s.Context.State = r.Header.Get("Tracestate")
+				s.Parent = parent.SpanID
+			} else {
+				randomID(s.Context.TraceID[:])
+				s.Context.Flags = flagSampled
+			}
+			randomID(s.Context.SpanID[:])
+
+			s.Name = r.Method
+			s.Attributes["http.method"] = r.Method
+			if Handler(ctx) != nil {
+				route := Template(ctx)
+				s.Name += " " + route
+				s.Attributes["http.route"] = route
+			}
+			vars, _ := ctx.Value(pattern.AllVariables).(map[pattern.Variable]interface{})
+			for name, value := range vars {
+				s.Attributes["goji.var."+string(name)] = fmt.Sprint(value)
+			}
+
+			rw := WrapResponseWriter(w)
+			defer func() {
+				s.End = time.Now()
+				status := rw.Status()
+				if status == 0 {
+					status = http.StatusOK
+				}
+				s.Attributes["http.status_code"] = strconv.Itoa(status)
+				if s.Context.Flags&flagSampled != 0 {
+					exporter.ExportSpan(*s)
+				}
+			}()
+			h.ServeHTTP(rw, r.WithContext(context.WithValue(ctx, spanKey, s)))
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// parseTraceparent parses a traceparent header, as described by the W3C Trace
+// Context specification.
+func parseTraceparent(h string) (SpanContext, bool) {
+	var sc SpanContext
+	// version "-" trace-id "-" parent-id "-" trace-flags, where later
+	// versions may append further fields.
+	if len(h) < 55 || h[2] != '-' || h[35] != '-' || h[52] != '-' {
+		return sc, false
+	}
+	version, ok := decodeHex(h[:2])
+	if !ok || version[0] == 0xff || version[0] == 0 && len(h) != 55 || len(h) > 55 && h[55] != '-' {
+		return sc, false
+	}
+	traceID, ok := decodeHex(h[3:35])
+	if !ok || isZero(traceID) {
+		return sc, false
+	}
+	spanID, ok := decodeHex(h[36:52])
+	if !ok || isZero(spanID) {
+		return sc, false
+	}
+	flags, ok := decodeHex(h[53:55])
+	if !ok {
+		return sc, false
+	}
+	copy(sc.TraceID[:], traceID)
+	copy(sc.SpanID[:], spanID)
+	sc.Flags = flags[0]
+	return sc, true
+}
+
+// decodeHex decodes lowercase hex, which is the only form the specification
+// allows.
+func decodeHex(s string) ([]byte, bool) {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return nil, false
+		}
+	}
+	b, err := hex.DecodeString(s)
+	return b, err == nil
+}
+
+func isZero(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// randomID fills b with random bytes, which are never all zero.
+func randomID(b []byte) {
+	for {
+		if _, err := crand.Read(b); err != nil {
+			mrand.Read(b)
+		}
+		if !isZero(b) {
+			return
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"goji.io"
+	"goji.io/pat"
+)
+
+var TraceparentTests = []struct {
+	header string
+	ok     bool
+}{
+	{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true},
+	{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", true},
+	{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", true},
+	{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false},
+	{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01extra", false},
+	{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false},
+	{"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", false},
+	{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", false},
+	{"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false},
+	{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-0g", false},
+	{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7", false},
+	{"", false},
+}
+
+func TestParseTraceparent(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range TraceparentTests {
+		sc, ok := parseTraceparent(test.header)
+		if ok != test.ok {
+			t.Errorf("[%q] expected ok=%v, got %v", test.header, test.ok, ok)
+			continue
+		}
+		if ok && sc.Traceparent()[3:55] != test.header[3:55] {
+			t.Errorf("[%q] round trip: got %q", test.header, sc.Traceparent())
+		}
+	}
+}
+
+func TestTracing(t *testing.T) {
+	t.Parallel()
+
+	var exporter MemoryExporter
+	m := goji.NewMux()
+	m.Use(Tracing(&exporter))
+	var outgoing http.Header
+	m.HandleFunc(pat.Get("/users/:name"), func(w http.ResponseWriter, r *http.Request) {
+		outgoing = make(http.Header)
+		InjectSpan(r.Context(), outgoing)
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	r, _ := http.NewRequest("GET", "/users/carl", nil)
+	r.Header.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.Header.Set("Tracestate", "vendor=value")
+	m.ServeHTTP(httptest.NewRecorder(), r)
+
+	spans := exporter.Spans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	s := spans[0]
+	if s.Name != "GET /users/:name" {
+		t.Errorf("name: expected %q, got %q", "GET /users/:name", s.Name)
+	}
+	if tid := s.Context.TraceID.String(); tid != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("trace ID: expected the parent's, got %s", tid)
+	}
+	if s.Parent.String() != "00f067aa0ba902b7" || s.Context.SpanID == s.Parent {
+		t.Errorf("expected a new span with parent 00f067aa0ba902b7, got %s with parent %s", s.Context.SpanID, s.Parent)
+	}
+	if s.Context.Flags != 1 || s.Context.State != "vendor=value" {
+		t.Errorf("expected flags and state to be propagated, got %x %q", s.Context.Flags, s.Context.State)
+	}
+	if s.End.Before(s.Start) {
+		t.Errorf("expected span to end after it started")
+	}
+	attrs := map[string]string{
+		"http.method":      "GET",
+		"http.route":       "/users/:name",
+		"http.status_code": "202",
+		"goji.var.name":    "carl",
+	}
+	if !reflect.DeepEqual(s.Attributes, attrs) {
+		t.Errorf("attributes: expected %v, got %v", attrs, s.Attributes)
+	}
+	if tp := outgoing.Get("Traceparent"); tp != s.Context.Traceparent() {
+		t.Errorf("traceparent: expected %q, got %q", s.Context.Traceparent(), tp)
+	}
+	if ts := outgoing.Get("Tracestate"); ts != "vendor=value" {
+		t.Errorf("tracestate: expected %q, got %q", "vendor=value", ts)
+	}
+
+	// Unsampled spans are propagated, but not exported.
+	exporter.Reset()
+	r, _ = http.NewRequest("GET", "/users/carl", nil)
+	r.Header.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	m.ServeHTTP(httptest.NewRecorder(), r)
+	if spans = exporter.Spans(); len(spans) != 0 {
+		t.Errorf("expected unsampled span not to be exported, got %+v", spans)
+	}
+	if tp := outgoing.Get("Traceparent"); !strings.HasPrefix(tp, "00-4bf92f3577b34da6a3ce929d0e0e4736-") || !strings.HasSuffix(tp, "-00") {
+		t.Errorf("expected unsampled traceparent to be propagated, got %q", tp)
+	}
+
+	exporter.Reset()
+	r, _ = http.NewRequest("POST", "/nope", nil)
+	r.Header.Set("Traceparent", "garbage")
+	m.ServeHTTP(httptest.NewRecorder(), r)
+	spans = exporter.Spans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	s = spans[0]
+	if s.Name != "POST" || s.Attributes["http.status_code"] != "404" {
+		t.Errorf("unexpected unmatched span %+v", s)
+	}
+	if s.Parent != (SpanID{}) || s.Context.Flags != 1 || s.Context.TraceID == (TraceID{}) {
+		t.Errorf("expected a new sampled trace, got %+v", s.Context)
+	}
+}
@@ -0,0 +1,181 @@
+package middleware
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+/*
+ResponseWriter is a http.ResponseWriter that records information about the
+response written through it. ResponseWriters are created with
+WrapResponseWriter.
+*/
+type ResponseWriter interface {
+	http.ResponseWriter
+	// Status returns the status code of the response, or 0 if the response
+	// has not yet been started. If a handler returns without writing a
+	// response, net/http responds with a 200 OK.
+	Status() int
+	// BytesWritten returns the number of bytes of the response body written
+	// so far.
+	BytesWritten() int64
+	// FirstByte returns the time at which the response was started, or the
+	// zero Time if it has not yet been started.
+	FirstByte() time.Time
+	// Unwrap returns the wrapped http.ResponseWriter. It allows
+	// http.ResponseController to reach the underlying writer.
+	Unwrap() http.ResponseWriter
+}
+
+/*
+WrapResponseWriter returns a ResponseWriter that writes to w.
+
+The returned ResponseWriter implements exactly the optional interfaces that w
+does, from among http.Flusher, http.Hijacker, io.ReaderFrom, and (since Go 1.8)
+http.Pusher, so wrapping a http.ResponseWriter does not change the behavior of
+handlers that test for them. Flushing a response, or copying into it using
+io.ReaderFrom, starts it in the same way writing to it does.
+*/
+func WrapResponseWriter(w http.ResponseWriter) ResponseWriter {
+	return wrap(&writer{ResponseWriter: w})
+}
+
+type writer struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+	first  time.Time
+}
+
+func (w *writer) start(code int) {
+	if w.status == 0 {
+		w.status = code
+		w.first = time.Now()
+	}
+}
+
+func (w *writer) WriteHeader(code int) {
+	// Informational responses (other than 101 Switching Protocols) are
+	// followed by the final response.
+	if code >= 200 || code == http.StatusSwitchingProtocols {
+		w.start(code)
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *writer) Write(b []byte) (int, error) {
+	w.start(http.StatusOK)
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+func (w *writer) Status() int {
+	return w.status
+}
+
+func (w *writer) BytesWritten() int64 {
+	return w.bytes
+}
+
+func (w *writer) FirstByte() time.Time {
+	return w.first
+}
+
+func (w *writer) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// The following types each implement one of the optional interfaces on behalf
+// of a writer. They are embedded alongside it in anonymous structs, so they
+// must not themselves embed it.
+
+type flusher struct{ w *writer }
+
+func (f flusher) Flush() {
+	f.w.start(http.StatusOK)
+	f.w.ResponseWriter.(http.Flusher).Flush()
+}
+
+type hijacker struct{ w *writer }
+
+func (h hijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return h.w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+type readerFrom struct{ w *writer }
+
+func (rf readerFrom) ReadFrom(r io.Reader) (int64, error) {
+	rf.w.start(http.StatusOK)
+	n, err := rf.w.ResponseWriter.(io.ReaderFrom).ReadFrom(r)
+	rf.w.bytes += n
+	return n, err
+}
+
+// interfaces returns a bit set of the optional interfaces implemented by w's
+// underlying http.ResponseWriter, other than http.Pusher.
+func (w *writer) interfaces() int {
+	var i int
+	if _, ok := w.ResponseWriter.(http.Flusher); ok {
+		i |= 1
+	}
+	if _, ok := w.ResponseWriter.(http.Hijacker); ok {
+		i |= 2
+	}
+	if _, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		i |= 4
+	}
+	return i
+}
+
+// wrapCommon returns w as a ResponseWriter which implements exactly the
+// optional interfaces in the given bit set (as returned by interfaces).
+func wrapCommon(w *writer, i int) ResponseWriter {
+	f, h, rf := flusher{w}, hijacker{w}, readerFrom{w}
+	switch i {
+	case 1:
+		return struct {
+			*writer
+			http.Flusher
+		}{w, f}
+	case 2:
+		return struct {
+			*writer
+			http.Hijacker
+		}{w, h}
+	case 3:
+		return struct {
+			*writer
+			http.Flusher
+			http.Hijacker
+		}{w, f, h}
+	case 4:
+		return struct {
+			*writer
+			io.ReaderFrom
+		}{w, rf}
+	case 5:
+		return struct {
+			*writer
+			http.Flusher
+			io.ReaderFrom
+		}{w, f, rf}
+	case 6:
+		return struct {
+			*writer
+			http.Hijacker
+			io.ReaderFrom
+		}{w, h, rf}
+	case 7:
+		return struct {
+			*writer
+			http.Flusher
+			http.Hijacker
+			io.ReaderFrom
+		}{w, f, h, rf}
+	}
+	return w
+}
@@ -0,0 +1,35 @@
+// +build go1.20
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// unwrapWriter hides the optional interfaces of the http.ResponseWriter it
+// wraps, but exposes it to http.ResponseController.
+type unwrapWriter struct {
+	http.ResponseWriter
+}
+
+func (w unwrapWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func TestWrapResponseWriterController(t *testing.T) {
+	t.Parallel()
+
+	rec := httptest.NewRecorder()
+	w := WrapResponseWriter(unwrapWriter{rec})
+	if _, ok := w.(http.Flusher); ok {
+		t.Error("expected wrapper not to implement http.Flusher")
+	}
+	if err := http.NewResponseController(w).Flush(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if !rec.Flushed {
+		t.Error("expected flush to be forwarded")
+	}
+}
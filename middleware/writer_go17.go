@@ -0,0 +1,7 @@
+// +build !go1.8
+
+package middleware
+
+func wrap(w *writer) ResponseWriter {
+	return wrapCommon(w, w.interfaces())
+}
@@ -0,0 +1,76 @@
+// +build go1.8
+
+package middleware
+
+import (
+	"io"
+	"net/http"
+)
+
+type pusher struct{ w *writer }
+
+func (p pusher) Push(target string, opts *http.PushOptions) error {
+	return p.w.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+func wrap(w *writer) ResponseWriter {
+	i := w.interfaces()
+	if _, ok := w.ResponseWriter.(http.Pusher); !ok {
+		return wrapCommon(w, i)
+	}
+
+	f, h, rf, p := flusher{w}, hijacker{w}, readerFrom{w}, pusher{w}
+	switch i {
+	case 1:
+		return struct {
+			*writer
+			http.Flusher
+			http.Pusher
+		}{w, f, p}
+	case 2:
+		return struct {
+			*writer
+			http.Hijacker
+			http.Pusher
+		}{w, h, p}
+	case 3:
+		return struct {
+			*writer
+			http.Flusher
+			http.Hijacker
+			http.Pusher
+		}{w, f, h, p}
+	case 4:
+		return struct {
+			*writer
+			io.ReaderFrom
+			http.Pusher
+		}{w, rf, p}
+	case 5:
+		return struct {
+			*writer
+			http.Flusher
+			io.ReaderFrom
+			http.Pusher
+		}{w, f, rf, p}
+	case 6:
+		return struct {
+			*writer
+			http.Hijacker
+			io.ReaderFrom
+			http.Pusher
+		}{w, h, rf, p}
+	case 7:
+		return struct {
+			*writer
+			http.Flusher
+			http.Hijacker
+			io.ReaderFrom
+			http.Pusher
+		}{w, f, h, rf, p}
+	}
+	return struct {
+		*writer
+		http.Pusher
+	}{w, p}
+}
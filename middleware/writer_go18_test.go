@@ -0,0 +1,44 @@
+// +build go1.8
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type pushWriter struct {
+	*httptest.ResponseRecorder
+	pushed string
+}
+
+func (w *pushWriter) Push(target string, opts *http.PushOptions) error {
+	w.pushed = target
+	return nil
+}
+
+func TestWrapResponseWriterPusher(t *testing.T) {
+	t.Parallel()
+
+	if _, ok := WrapResponseWriter(httptest.NewRecorder()).(http.Pusher); ok {
+		t.Error("expected wrapper not to implement http.Pusher")
+	}
+
+	pw := &pushWriter{ResponseRecorder: httptest.NewRecorder()}
+	w := WrapResponseWriter(pw)
+	p, ok := w.(http.Pusher)
+	if !ok {
+		t.Fatal("expected wrapper to implement http.Pusher")
+	}
+	if _, ok := w.(http.Flusher); !ok {
+		t.Error("expected wrapper to implement http.Flusher")
+	}
+	if _, ok := w.(http.Hijacker); ok {
+		t.Error("expected wrapper not to implement http.Hijacker")
+	}
+	p.Push("/style.css", nil)
+	if pw.pushed != "/style.css" {
+		t.Errorf("expected push to be forwarded, got %q", pw.pushed)
+	}
+}
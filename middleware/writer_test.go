@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// plainWriter implements none of the optional interfaces.
+type plainWriter struct {
+	http.ResponseWriter
+}
+
+// fullWriter implements all of the optional interfaces other than http.Pusher.
+type fullWriter struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+	readFrom bool
+}
+
+func (w *fullWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.hijacked = true
+	return nil, nil, nil
+}
+
+func (w *fullWriter) ReadFrom(r io.Reader) (int64, error) {
+	w.readFrom = true
+	return io.Copy(w.ResponseRecorder, r)
+}
+
+func TestWrapResponseWriter(t *testing.T) {
+	t.Parallel()
+
+	rec := httptest.NewRecorder()
+	w := WrapResponseWriter(plainWriter{rec})
+	if _, ok := w.(http.Flusher); ok {
+		t.Error("expected wrapper not to implement http.Flusher")
+	}
+	if _, ok := w.(http.Hijacker); ok {
+		t.Error("expected wrapper not to implement http.Hijacker")
+	}
+	if _, ok := w.(io.ReaderFrom); ok {
+		t.Error("expected wrapper not to implement io.ReaderFrom")
+	}
+	if w.Status() != 0 || !w.FirstByte().IsZero() {
+		t.Errorf("expected response not to be started, got status %d", w.Status())
+	}
+
+	w.WriteHeader(http.StatusNotFound)
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("hello"))
+	w.Write([]byte(" world"))
+	if w.Status() != http.StatusNotFound {
+		t.Errorf("status: expected %d, got %d", http.StatusNotFound, w.Status())
+	}
+	if w.BytesWritten() != 11 {
+		t.Errorf("bytes: expected %d, got %d", 11, w.BytesWritten())
+	}
+	if w.FirstByte().IsZero() {
+		t.Error("expected first byte time to be set")
+	}
+	if w.Unwrap() != (plainWriter{rec}) {
+		t.Error("expected Unwrap to return the wrapped writer")
+	}
+}
+
+func TestWrapResponseWriterInterfaces(t *testing.T) {
+	t.Parallel()
+
+	fw := &fullWriter{ResponseRecorder: httptest.NewRecorder()}
+	w := WrapResponseWriter(fw)
+
+	rf, ok := w.(io.ReaderFrom)
+	if !ok {
+		t.Fatal("expected wrapper to implement io.ReaderFrom")
+	}
+	n, _ := rf.ReadFrom(strings.NewReader("hello"))
+	if n != 5 || !fw.readFrom || w.BytesWritten() != 5 || w.Status() != http.StatusOK {
+		t.Errorf("ReadFrom: got n=%d readFrom=%v bytes=%d status=%d", n, fw.readFrom, w.BytesWritten(), w.Status())
+	}
+
+	h, ok := w.(http.Hijacker)
+	if !ok {
+		t.Fatal("expected wrapper to implement http.Hijacker")
+	}
+	h.Hijack()
+	if !fw.hijacked {
+		t.Error("expected Hijack to be forwarded")
+	}
+
+	f, ok := w.(http.Flusher)
+	if !ok {
+		t.Fatal("expected wrapper to implement http.Flusher")
+	}
+	f.Flush()
+	if !fw.Flushed {
+		t.Error("expected Flush to be forwarded")
+	}
+}
+
+func TestWrapResponseWriterInformational(t *testing.T) {
+	t.Parallel()
+
+	w := WrapResponseWriter(httptest.NewRecorder())
+	w.WriteHeader(http.StatusContinue)
+	if w.Status() != 0 {
+		t.Errorf("status: expected 0 after a 100, got %d", w.Status())
+	}
+}
+
+func TestWrapResponseWriterFlush(t *testing.T) {
+	t.Parallel()
+
+	rec := httptest.NewRecorder()
+	w := WrapResponseWriter(rec)
+	w.(http.Flusher).Flush()
+	if w.Status() != http.StatusOK || !rec.Flushed {
+		t.Errorf("expected flush to start the response, got status %d", w.Status())
+	}
+}
@@ -2,7 +2,11 @@ package goji
 
 import (
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+
+	"goji.io/pattern"
 )
 
 func expectSequence(t *testing.T, ch chan string, seq ...string) {
@@ -78,3 +82,55 @@ func TestMiddlewareReconfigure(t *testing.T) {
 	expectSequence(t, ch, "before one", "before two", "before three",
 		"handler", "after three", "after two", "after one")
 }
+
+func TestUsePre(t *testing.T) {
+	t.Parallel()
+
+	m := NewMux()
+	ch := make(chan string, 10)
+	m.UsePre(makeMiddleware(ch, "pre"))
+	m.UsePre(func(h http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if method := r.Header.Get("X-HTTP-Method-Override"); method != "" {
+				r.Method = method
+			}
+			ctx := r.Context()
+			path := strings.TrimPrefix(pattern.Path(ctx), "/v1")
+			h.ServeHTTP(w, r.WithContext(pattern.SetPath(ctx, path)))
+		}
+		return http.HandlerFunc(fn)
+	})
+	m.Use(makeMiddleware(ch, "post"))
+	mark := new(int)
+	m.HandleFunc(testPattern{mark: mark, methods: []string{"DELETE"}, prefix: "/a"}, func(w http.ResponseWriter, r *http.Request) {
+		ch <- "delete " + pattern.Path(r.Context())
+	})
+	m.HandleFunc(testPattern{mark: mark, prefix: "/"}, func(w http.ResponseWriter, r *http.Request) {
+		ch <- r.Method + " " + pattern.Path(r.Context())
+	})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/v1/a", nil)
+	r.Header.Set("X-HTTP-Method-Override", "DELETE")
+	m.ServeHTTP(w, r)
+	expectSequence(t, ch, "before pre", "before post", "delete /a", "after post", "after pre")
+
+	r, _ = http.NewRequest("POST", "/v1/a", nil)
+	m.ServeHTTP(w, r)
+	expectSequence(t, ch, "before pre", "before post", "POST /a", "after post", "after pre")
+}
+
+func TestUsePreCopyOnWrite(t *testing.T) {
+	t.Parallel()
+
+	m := NewMux()
+	m.CopyOnWrite()
+	ch := make(chan string, 10)
+	m.UsePre(makeMiddleware(ch, "pre"))
+	m.Handle(boolPattern(true), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ch <- "handler"
+	}))
+
+	m.ServeHTTP(wr())
+	expectSequence(t, ch, "before pre", "handler", "after pre")
+}
@@ -18,13 +18,27 @@ on the Pattern type for more information about request matching, and the
 documentation for the Use method for more about middleware.
 
 Muxes cannot be configured concurrently from multiple goroutines, nor can they
-be configured concurrently with requests.
+be configured concurrently with requests, unless they have been put into
+copy-on-write mode with CopyOnWrite.
 */
 type Mux struct {
 	handler    http.Handler
 	middleware []func(http.Handler) http.Handler
+	pre        []func(http.Handler) http.Handler
+	preHandler http.Handler
 	router     router
 	root       bool
+	entries    []entry
+	names      map[string]*Registration
+
+	notFound      http.Handler
+	autoOptions   bool
+	strict        bool
+	redirect      RedirectPolicy
+	tracing       bool
+	profileLabels bool
+
+	live *live
 }
 
 /*
@@ -65,12 +79,29 @@ func SubMux() *Mux {
 
 // ServeHTTP implements net/http.Handler.
 func (m *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m = m.current()
 	if m.root {
 		ctx := r.Context()
 		ctx = context.WithValue(ctx, internal.Path, r.URL.EscapedPath())
 		r = r.WithContext(ctx)
 	}
-	r = m.router.route(r)
+	if m.preHandler != nil {
+		m.preHandler.ServeHTTP(w, r)
+	} else {
+		m.serve(w, r)
+	}
+}
+
+// serve routes the request and passes it to the middleware stack.
+func (m *Mux) serve(w http.ResponseWriter, r *http.Request) {
+	t, r := m.trace(r)
+	r = m.router.trace(r, t)
+	if t != nil {
+		t.routed(w)
+	}
+	if m.redirect != 0 && r.Context().Value(internal.Handler) == nil {
+		r = m.redirectTo(r)
+	}
 	m.handler.ServeHTTP(w, r)
 }
 
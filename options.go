@@ -0,0 +1,25 @@
+package goji
+
+/*
+AutoOptions controls whether the Mux automatically responds to OPTIONS requests.
+
+When enabled, an OPTIONS request that is not matched by any route, but whose
+path would have been matched by routes for other HTTP methods, is answered with
+a 204 No Content and an Allow header listing those methods (and OPTIONS
+itself). The set of methods is computed in the same way as for 405 Method Not
+Allowed responses; see the documentation for Handle for more. Since automatic
+responses are only generated when routing fails, routes that explicitly match
+OPTIONS requests (for instance, those created with pat.Options) always take
+precedence.
+
+Automatic OPTIONS responses are generated at the end of the middleware stack,
+and so are visible to middleware in the same way that ordinary routes are.
+AutoOptions is disabled by default. It is not safe to call AutoOptions
+concurrently with requests unless the Mux is in copy-on-write mode.
+*/
+func (m *Mux) AutoOptions(enabled bool) {
+	m.update(func(m *Mux) {
+		m.autoOptions = enabled
+		m.buildChain()
+	})
+}
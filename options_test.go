@@ -0,0 +1,46 @@
+package goji
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var AutoOptionsTests = []struct {
+	auto         bool
+	method, path string
+	code         int
+	allow        string
+}{
+	{false, "OPTIONS", "/a", 405, "GET, HEAD"},
+	{true, "OPTIONS", "/a", 204, "GET, HEAD, OPTIONS"},
+	{true, "POST", "/a", 405, "GET, HEAD, OPTIONS"},
+	{true, "OPTIONS", "/b", 200, ""},
+	{true, "OPTIONS", "/c", 404, ""},
+}
+
+func TestAutoOptions(t *testing.T) {
+	t.Parallel()
+
+	mark := new(int)
+	for _, test := range AutoOptionsTests {
+		m := NewMux()
+		m.Handle(testPattern{mark: mark, methods: []string{"GET", "HEAD"}, prefix: "/a"}, intHandler(0))
+		m.Handle(testPattern{mark: mark, methods: []string{"OPTIONS"}, prefix: "/b"}, intHandler(1))
+		m.AutoOptions(test.auto)
+
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest(test.method, test.path, nil)
+		if err != nil {
+			panic(err)
+		}
+		m.ServeHTTP(w, r)
+
+		if w.Code != test.code {
+			t.Errorf("[%v %s %s] status: expected %d, got %d", test.auto, test.method, test.path, test.code, w.Code)
+		}
+		if allow := w.Header().Get("Allow"); allow != test.allow {
+			t.Errorf("[%v %s %s] Allow: expected %q, got %q", test.auto, test.method, test.path, test.allow, allow)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package pat
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+
+	"goji.io/pattern"
+)
+
+/*
+HostPattern implements goji.Pattern by matching the host name of requests. Host
+names are matched label by label: a label beginning with a ":" matches any
+(non-empty) label and binds it to a variable, and every other label must match
+exactly, ignoring case. For instance, the pattern
+
+	:tenant.example.com
+
+matches requests for "acme.example.com", binding "tenant" to "acme", but does
+not match requests for "example.com" or "acme.eu.example.com". The request's
+port, if any, is ignored.
+
+Variables bound by a HostPattern can be retrieved in the same manner as those
+bound by Pattern, for instance using the Param function. HostPatterns do not
+examine or consume the request's path, which makes them convenient to combine
+with a SubMux that performs path-based routing:
+
+	tenants := goji.SubMux()
+	tenants.HandleFunc(pat.Get("/orders/:id"), order)
+	root.Handle(pat.Host(":tenant.example.com"), tenants)
+*/
+type HostPattern struct {
+	raw    string
+	labels []string
+	// Sorted by name, like Pattern's.
+	pats patNames
+}
+
+/*
+Host returns a new HostPattern from the given host name pattern. See the
+documentation for HostPattern for more information about what syntax is
+accepted by this function.
+*/
+func Host(host string) *HostPattern {
+	p := &HostPattern{raw: host}
+	p.labels = strings.Split(strings.ToLower(host), ".")
+	for i, label := range p.labels {
+		if strings.HasPrefix(label, ":") {
+			// Preserve the case of variable names
+			name := strings.Split(host, ".")[i][1:]
+			p.pats = append(p.pats, struct {
+				name pattern.Variable
+				idx  int
+			}{pattern.Variable(name), i})
+		}
+	}
+	sort.Sort(p.pats)
+	return p
+}
+
+/*
+Match runs the HostPattern on the given request, returning a non-nil output
+request if the input request's host matches the pattern.
+
+This function satisfies goji.Pattern.
+*/
+func (p *HostPattern) Match(r *http.Request) *http.Request {
+	labels := strings.Split(pattern.Host(r), ".")
+	if len(labels) != len(p.labels) {
+		return nil
+	}
+	for i, label := range p.labels {
+		if strings.HasPrefix(label, ":") {
+			if labels[i] == "" {
+				return nil
+			}
+		} else if labels[i] != label {
+			return nil
+		}
+	}
+	if len(p.pats) == 0 {
+		return r
+	}
+	return r.WithContext(&hostMatch{r.Context(), p, labels})
+}
+
+/*
+Host returns the host name that all requests this HostPattern matches must have
+or, if the pattern contains variables, the suffix (beginning with a ".") that
+follows the last variable. For instance, the host pattern ":tenant.example.com"
+returns ".example.com". If the pattern ends with a variable, the empty string is
+returned.
+
+This function satisfies goji's Host Pattern optimization.
+*/
+func (p *HostPattern) Host() string {
+	last := -1
+	for i, label := range p.labels {
+		if strings.HasPrefix(label, ":") {
+			last = i
+		}
+	}
+	if last == len(p.labels)-1 {
+		return ""
+	}
+	suffix := strings.Join(p.labels[last+1:], ".")
+	if last >= 0 {
+		suffix = "." + suffix
+	}
+	return suffix
+}
+
+/*
+String returns the host pattern string that was used to create this
+HostPattern.
+*/
+func (p *HostPattern) String() string {
+	return p.raw
+}
+
+type hostMatch struct {
+	context.Context
+	pat    *HostPattern
+	labels []string
+}
+
+func (m hostMatch) Value(key interface{}) interface{} {
+	if key == pattern.AllVariables {
+		var vs map[pattern.Variable]interface{}
+		if vsi := m.Context.Value(key); vsi == nil {
+			vs = make(map[pattern.Variable]interface{}, len(m.pat.pats))
+		} else {
+			vs = vsi.(map[pattern.Variable]interface{})
+		}
+
+		for _, p := range m.pat.pats {
+			vs[p.name] = m.labels[p.idx]
+		}
+		return vs
+	}
+
+	if k, ok := key.(pattern.Variable); ok {
+		i := sort.Search(len(m.pat.pats), func(i int) bool {
+			return m.pat.pats[i].name >= k
+		})
+		if i < len(m.pat.pats) && m.pat.pats[i].name == k {
+			return m.labels[m.pat.pats[i].idx]
+		}
+	}
+
+	return m.Context.Value(key)
+}
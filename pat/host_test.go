@@ -0,0 +1,102 @@
+package pat
+
+import (
+	"reflect"
+	"testing"
+
+	"goji.io/pattern"
+)
+
+var HostTests = []struct {
+	pat   string
+	host  string
+	match bool
+	vars  map[pattern.Variable]interface{}
+}{
+	{"example.com", "example.com", true, nil},
+	{"example.com", "EXAMPLE.com:8000", true, nil},
+	{"example.com", "www.example.com", false, nil},
+	{":tenant.example.com", "acme.example.com", true, pv{"tenant": "acme"}},
+	{":tenant.example.com", "acme.example.com.", true, pv{"tenant": "acme"}},
+	{":tenant.example.com", "example.com", false, nil},
+	{":tenant.example.com", ".example.com", false, nil},
+	{":tenant.example.com", "acme.eu.example.com", false, nil},
+	{":tenant.:region.example.com", "acme.eu.example.com", true, pv{"tenant": "acme", "region": "eu"}},
+	{"api.:Region", "API.eu:443", true, pv{"Region": "eu"}},
+}
+
+func TestHost(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range HostTests {
+		pat := Host(test.pat)
+		if str := pat.String(); str != test.pat {
+			t.Errorf("[%q %q] String()=%q, expected=%q", test.pat, test.host, str, test.pat)
+		}
+
+		req := mustReq("GET", "/hello")
+		req.Host = test.host
+		req = pat.Match(req)
+		if (req != nil) != test.match {
+			t.Errorf("[%q %q] match=%v, expected=%v", test.pat, test.host, req != nil, test.match)
+		}
+		if req == nil {
+			continue
+		}
+
+		ctx := req.Context()
+		if path := pattern.Path(ctx); path != "/hello" {
+			t.Errorf("[%q %q] path=%q, expected=%q", test.pat, test.host, path, "/hello")
+		}
+		vars, _ := ctx.Value(pattern.AllVariables).(map[pattern.Variable]interface{})
+		if !reflect.DeepEqual(vars, test.vars) {
+			t.Errorf("[%q %q] vars=%v, expected=%v", test.pat, test.host, vars, test.vars)
+		}
+		for k, v := range test.vars {
+			if p := Param(req, string(k)); p != v {
+				t.Errorf("[%q %q] %s=%q, expected %q", test.pat, test.host, k, p, v)
+			}
+		}
+	}
+}
+
+func TestHostWithPath(t *testing.T) {
+	t.Parallel()
+
+	req := mustReq("GET", "/users/carl")
+	req.Host = "acme.example.com"
+	req = Host(":tenant.example.com").Match(req)
+	req = New("/users/:name").Match(req)
+	if req == nil {
+		t.Fatal("expected a match")
+	}
+
+	expected := map[pattern.Variable]interface{}{"tenant": "acme", "name": "carl"}
+	if vars := req.Context().Value(pattern.AllVariables); !reflect.DeepEqual(vars, expected) {
+		t.Errorf("vars=%v, expected %v", vars, expected)
+	}
+	if p := Param(req, "tenant"); p != "acme" {
+		t.Errorf("tenant=%q, expected %q", p, "acme")
+	}
+}
+
+var HostHintTests = []struct {
+	pat  string
+	host string
+}{
+	{"example.com", "example.com"},
+	{"Example.COM", "example.com"},
+	{":tenant.example.com", ".example.com"},
+	{"api.:region.example.com", ".example.com"},
+	{"api.:region", ""},
+}
+
+func TestHostHint(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range HostHintTests {
+		if host := Host(test.pat).Host(); host != test.host {
+			t.Errorf("%q.Host() = %q, expected %q", test.pat, host, test.host)
+		}
+	}
+}
@@ -121,6 +121,10 @@ type Pattern struct {
 	breaks   []byte
 	literals []string
 	wildcard bool
+
+	// Used to compare patterns; see relation.go.
+	auto automaton
+	segs segments
 }
 
 // "Break characters" are characters that can end patterns. They are not allowed
@@ -167,6 +171,9 @@ func New(pat string) *Pattern {
 
 	sort.Sort(p.pats)
 
+	p.auto = p.automaton()
+	p.segs = p.auto.segments()
+
 	return p
 }
 
@@ -252,6 +259,30 @@ func (p *Pattern) PathPrefix() string {
 	return p.literals[0]
 }
 
+/*
+PathSegments returns a description of the structure of the paths this Pattern
+matches, as a sequence of literals and variables, optionally followed by a
+wildcard.
+
+This function satisfies goji's PathSegments Pattern optimization.
+*/
+func (p *Pattern) PathSegments() []pattern.Segment {
+	segs := make([]pattern.Segment, 0, 2*len(p.pats)+2)
+	for i := range p.pats {
+		if p.literals[i] != "" {
+			segs = append(segs, pattern.Segment{Kind: pattern.LiteralSegment, Literal: p.literals[i]})
+		}
+		segs = append(segs, pattern.Segment{Kind: pattern.VariableSegment, Break: p.breaks[i]})
+	}
+	if tail := p.literals[len(p.pats)]; tail != "" {
+		segs = append(segs, pattern.Segment{Kind: pattern.LiteralSegment, Literal: tail})
+	}
+	if p.wildcard {
+		segs = append(segs, pattern.Segment{Kind: pattern.WildcardSegment})
+	}
+	return segs
+}
+
 /*
 HTTPMethods returns a set of HTTP methods that all requests that this
 Pattern matches must be in, or nil if it's not possible to determine
@@ -168,3 +168,33 @@ func TestParam(t *testing.T) {
 		t.Errorf("name=%q, expected %q", name, "carl")
 	}
 }
+
+var PathSegmentsTests = []struct {
+	pat  string
+	segs []pattern.Segment
+}{
+	{"/", []pattern.Segment{{Kind: pattern.LiteralSegment, Literal: "/"}}},
+	{"/user/:name/*", []pattern.Segment{
+		{Kind: pattern.LiteralSegment, Literal: "/user/"},
+		{Kind: pattern.VariableSegment, Break: '/'},
+		{Kind: pattern.LiteralSegment, Literal: "/"},
+		{Kind: pattern.WildcardSegment},
+	}},
+	{"/:file.:ext", []pattern.Segment{
+		{Kind: pattern.LiteralSegment, Literal: "/"},
+		{Kind: pattern.VariableSegment, Break: '.'},
+		{Kind: pattern.LiteralSegment, Literal: "."},
+		{Kind: pattern.VariableSegment, Break: '/'},
+	}},
+}
+
+func TestPathSegments(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range PathSegmentsTests {
+		pat := New(test.pat)
+		if segs := pat.PathSegments(); !reflect.DeepEqual(segs, test.segs) {
+			t.Errorf("%q.PathSegments() = %v, expected %v", test.pat, segs, test.segs)
+		}
+	}
+}
@@ -0,0 +1,367 @@
+package pat
+
+import (
+	"sort"
+	"strings"
+)
+
+// Every Pat pattern matches a regular language of (escaped) paths: literals
+// match themselves, a variable matches a non-empty run of bytes that contains
+// neither a slash nor its break character, and a wildcard matches anything at
+// all. We compare patterns by simulating the corresponding automata, whose
+// states are positions in the list of tokens below. Being inside of a
+// variable is tracked using an extra bit on the state.
+
+type tokenKind int
+
+const (
+	tokLiteral tokenKind = iota
+	tokVariable
+	tokWildcard
+)
+
+type token struct {
+	kind tokenKind
+	// For literals, the byte to match. For variables, the break character.
+	c byte
+}
+
+type automaton []token
+
+func (p *Pattern) automaton() automaton {
+	var a automaton
+	for i := range p.pats {
+		for j := 0; j < len(p.literals[i]); j++ {
+			a = append(a, token{tokLiteral, p.literals[i][j]})
+		}
+		a = append(a, token{tokVariable, p.breaks[i]})
+	}
+	tail := p.literals[len(p.pats)]
+	for j := 0; j < len(tail); j++ {
+		a = append(a, token{tokLiteral, tail[j]})
+	}
+	if p.wildcard {
+		a = append(a, token{kind: tokWildcard})
+	}
+	return a
+}
+
+// States are encoded as twice the token index, plus one if we are inside of a
+// variable.
+func (a automaton) accepts(state int) bool {
+	i := state / 2
+	return i == len(a) || a[i].kind == tokWildcard
+}
+
+// closure adds the given state to the set, along with every state that can be
+// reached from it without consuming input.
+func (a automaton) closure(set []int, state int) []int {
+	for _, s := range set {
+		if s == state {
+			return set
+		}
+	}
+	set = append(set, state)
+	if state%2 == 1 {
+		set = a.closure(set, state+1)
+	}
+	return set
+}
+
+func (a automaton) step(set []int, state int, c byte) []int {
+	i := state / 2
+	if i == len(a) {
+		return set
+	}
+	switch tok := a[i]; tok.kind {
+	case tokLiteral:
+		if c == tok.c {
+			set = a.closure(set, 2*(i+1))
+		}
+	case tokVariable:
+		if c != '/' && c != tok.c {
+			set = a.closure(set, 2*i+1)
+		}
+	case tokWildcard:
+		set = a.closure(set, state)
+	}
+	return set
+}
+
+// alphabet returns a set of bytes which is representative of all inputs to
+// the given automata: every byte either appears in one of the automata, or
+// behaves identically to the extra byte we add.
+func alphabet(a, b automaton) []byte {
+	var seen [256]bool
+	seen['/'] = true
+	for _, tok := range a {
+		seen[tok.c] = true
+	}
+	for _, tok := range b {
+		seen[tok.c] = true
+	}
+	var out []byte
+	extra := -1
+	for c := 1; c < 256; c++ {
+		if seen[c] {
+			out = append(out, byte(c))
+		} else if extra < 0 {
+			extra = c
+		}
+	}
+	return append(out, byte(extra))
+}
+
+func setKey(set []int) string {
+	sorted := append([]int(nil), set...)
+	sort.Ints(sorted)
+	buf := make([]byte, 0, 4*len(sorted))
+	for _, s := range sorted {
+		buf = append(buf, byte(s>>24), byte(s>>16), byte(s>>8), byte(s))
+	}
+	return string(buf)
+}
+
+// segments describes the slash-separated segments of the paths an automaton
+// matches. Since variables never match slashes, every path matched by an
+// automaton without a wildcard has exactly as many segments as the automaton,
+// and every path matched by one with a wildcard has at least as many.
+type segments struct {
+	// The literal text of each segment without a variable.
+	literals []string
+	// Whether each segment contains a variable.
+	variable []bool
+	// Whether the last segment is followed by a wildcard, and so only
+	// constrains a prefix of the rest of the path.
+	open bool
+}
+
+func (a automaton) segments() segments {
+	var s segments
+	var lit []byte
+	variable := false
+	for _, tok := range a {
+		switch tok.kind {
+		case tokLiteral:
+			if tok.c == '/' {
+				s.literals = append(s.literals, string(lit))
+				s.variable = append(s.variable, variable)
+				lit, variable = lit[:0], false
+			} else {
+				lit = append(lit, tok.c)
+			}
+		case tokVariable:
+			variable = true
+		case tokWildcard:
+			s.open = true
+		}
+	}
+	s.literals = append(s.literals, string(lit))
+	s.variable = append(s.variable, variable)
+	return s
+}
+
+// disjoint is a cheap test that rules out most pairs of patterns before we run
+// the full comparison. It reports whether the patterns cannot match a common
+// path, either because they require different numbers of segments, or because
+// some segment is a different literal in each.
+func disjoint(p, q *Pattern) bool {
+	a, b := p.literals[0], q.literals[0]
+	if len(b) < len(a) {
+		a, b = b, a
+	}
+	if b[:len(a)] != a {
+		return true
+	}
+
+	ps, qs := p.segs, q.segs
+	if !ps.open && !qs.open && len(ps.literals) != len(qs.literals) ||
+		ps.open && !qs.open && len(qs.literals) < len(ps.literals) ||
+		qs.open && !ps.open && len(ps.literals) < len(qs.literals) {
+		return true
+	}
+	// Compare the segments both patterns constrain completely.
+	n := len(ps.literals)
+	if ps.open {
+		n--
+	}
+	if m := len(qs.literals); qs.open && m-1 < n {
+		n = m - 1
+	} else if m < n {
+		n = m
+	}
+	for i := 0; i < n; i++ {
+		if !ps.variable[i] && !qs.variable[i] && ps.literals[i] != qs.literals[i] {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+CoversPath reports whether every path matched by other is also matched by this
+Pattern, ignoring HTTP methods. It returns false if other is not a *Pattern.
+
+For instance, "/users/*" covers "/users/:name", which in turn covers
+"/users/carl", but "/users/:name" does not cover "/users/*".
+*/
+func (p *Pattern) CoversPath(other interface{}) bool {
+	q, ok := other.(*Pattern)
+	if !ok {
+		return false
+	}
+	if disjoint(p, q) {
+		return false
+	}
+
+	pa, qa := p.auto, q.auto
+	sigma := alphabet(pa, qa)
+
+	// We run q's automaton one state at a time, alongside the set of states
+	// p's automaton might be in. If q can ever accept a path p does not,
+	// p does not cover q.
+	type pair struct {
+		q int
+		p []int
+	}
+	var queue []pair
+	seen := make(map[int]map[string]bool)
+	visit := func(qs int, ps []int) {
+		key := setKey(ps)
+		if seen[qs] == nil {
+			seen[qs] = make(map[string]bool)
+		}
+		if !seen[qs][key] {
+			seen[qs][key] = true
+			queue = append(queue, pair{qs, ps})
+		}
+	}
+
+	start := pa.closure(nil, 0)
+	for _, qs := range qa.closure(nil, 0) {
+		visit(qs, start)
+	}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+
+		if qa.accepts(cur.q) {
+			accepted := false
+			for _, ps := range cur.p {
+				accepted = accepted || pa.accepts(ps)
+			}
+			if !accepted {
+				return false
+			}
+		}
+
+		for _, c := range sigma {
+			next := qa.step(nil, cur.q, c)
+			if len(next) == 0 {
+				continue
+			}
+			var pnext []int
+			for _, ps := range cur.p {
+				pnext = pa.step(pnext, ps, c)
+			}
+			// Every state of q's automaton can eventually reach
+			// an accepting state, so if p's automaton is stuck, q
+			// will eventually match a path p does not.
+			if len(pnext) == 0 {
+				return false
+			}
+			for _, qs := range next {
+				visit(qs, pnext)
+			}
+		}
+	}
+	return true
+}
+
+/*
+OverlapsPath reports whether there is at least one path that is matched by both
+this Pattern and other, ignoring HTTP methods. It returns false if other is not
+a *Pattern.
+
+For instance, "/:name/photos" and "/carl/:album" overlap, since both match
+"/carl/photos".
+*/
+func (p *Pattern) OverlapsPath(other interface{}) bool {
+	q, ok := other.(*Pattern)
+	if !ok {
+		return false
+	}
+	if disjoint(p, q) {
+		return false
+	}
+
+	pa, qa := p.auto, q.auto
+	sigma := alphabet(pa, qa)
+
+	type pair struct{ p, q int }
+	var queue []pair
+	seen := make(map[pair]bool)
+	for _, ps := range pa.closure(nil, 0) {
+		for _, qs := range qa.closure(nil, 0) {
+			seen[pair{ps, qs}] = true
+			queue = append(queue, pair{ps, qs})
+		}
+	}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+
+		if pa.accepts(cur.p) && qa.accepts(cur.q) {
+			return true
+		}
+		for _, c := range sigma {
+			for _, ps := range pa.step(nil, cur.p, c) {
+				for _, qs := range qa.step(nil, cur.q, c) {
+					if next := (pair{ps, qs}); !seen[next] {
+						seen[next] = true
+						queue = append(queue, next)
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+/*
+JoinPath returns a Pattern which matches the paths matched by this Pattern when
+the rest of the path (see the documentation for Prefix Matches) is matched by
+inner, ignoring HTTP methods. It returns nil unless this Pattern ends in a
+wildcard and inner is a *Pattern.
+
+For instance, "/users/:name/*" joined with "/photos/:id" matches the same paths
+as "/users/:name/photos/:id". This allows the routes of a goji.Group to be
+compared with CoversPath and OverlapsPath.
+*/
+func (p *Pattern) JoinPath(inner interface{}) interface{} {
+	q, ok := inner.(*Pattern)
+	if !ok || !p.wildcard {
+		return nil
+	}
+
+	// The rest of the path begins with the slash p's last literal ends
+	// with, which is shared with the first of q's.
+	n := len(p.pats)
+	j := &Pattern{
+		raw:      strings.TrimSuffix(p.raw, "/*") + q.raw,
+		pats:     make(patNames, n+len(q.pats)),
+		breaks:   append(p.breaks[:n:n], q.breaks...),
+		literals: append(p.literals[:n:n], p.literals[n][:len(p.literals[n])-1]+q.literals[0]),
+		wildcard: q.wildcard,
+	}
+	j.literals = append(j.literals, q.literals[1:]...)
+	copy(j.pats, p.pats)
+	for i, pat := range q.pats {
+		pat.idx += n
+		j.pats[n+i] = pat
+	}
+	sort.Sort(j.pats)
+	j.auto = j.automaton()
+	j.segs = j.auto.segments()
+	return j
+}
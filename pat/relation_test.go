@@ -0,0 +1,101 @@
+package pat
+
+import (
+	"testing"
+
+	"goji.io/pattern"
+)
+
+var RelationTests = []struct {
+	p, q     string
+	covers   bool
+	overlaps bool
+}{
+	{"/", "/", true, true},
+	{"/hello", "/hello", true, true},
+	{"/hello", "/hi", false, false},
+	{"/hello", "/hello/", false, false},
+	{"/users/*", "/users/:name", true, true},
+	{"/users/:name", "/users/*", false, true},
+	{"/users/:name", "/users/carl", true, true},
+	{"/users/carl", "/users/:name", false, true},
+	{"/users/:id", "/users/:name", true, true},
+	{"/users/:name", "/users/:name/", false, false},
+	{"/*", "/users/:name/photos", true, true},
+	{"/:name/photos", "/carl/:album", false, true},
+	{"/:name/photos", "/carl/albums", false, false},
+	{"/:file.:ext", "/:name", false, true},
+	{"/:name", "/:file.:ext", true, true},
+	{"/:file.:ext", "/data.json", true, true},
+	{"/:file.json", "/:file.:ext", false, true},
+	{"/:file.:ext", "/:file.json", true, true},
+	{"/:name", "/users/:name", false, false},
+	{"/users/:name/*", "/users/:name/photos/:id", true, true},
+	{"/users/:name/*", "/users/*", false, true},
+	{"/:a/x/:b", "/:a/y/:b", false, false},
+	{"/:a/x.:b", "/:a/x/:b", false, false},
+	{"/a/*", "/a", false, false},
+	{"/:a/*", "/x/y/z", true, true},
+	{"/x/*", "/:a/b.:c", false, true},
+	{"/a.:b/c", "/a.x/:d", false, true},
+}
+
+func TestRelations(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range RelationTests {
+		p, q := New(test.p), New(test.q)
+		if covers := p.CoversPath(q); covers != test.covers {
+			t.Errorf("%q.CoversPath(%q) = %v, expected %v", test.p, test.q, covers, test.covers)
+		}
+		if overlaps := p.OverlapsPath(q); overlaps != test.overlaps {
+			t.Errorf("%q.OverlapsPath(%q) = %v, expected %v", test.p, test.q, overlaps, test.overlaps)
+		}
+		if overlaps := q.OverlapsPath(p); overlaps != test.overlaps {
+			t.Errorf("%q.OverlapsPath(%q) = %v, expected %v", test.q, test.p, overlaps, test.overlaps)
+		}
+		if disjoint(p, q) && test.overlaps {
+			t.Errorf("expected %q and %q not to be disjoint", test.p, test.q)
+		}
+	}
+
+	if New("/").CoversPath(nil) || New("/").OverlapsPath("/") {
+		t.Error("expected patterns of a different type to be unrelated")
+	}
+}
+
+func TestJoinPath(t *testing.T) {
+	t.Parallel()
+
+	j, ok := New("/users/:name/*").JoinPath(New("/photos/:id/*")).(*Pattern)
+	if !ok {
+		t.Fatal("expected a *Pattern")
+	}
+	if s := j.String(); s != "/users/:name/photos/:id/*" {
+		t.Errorf("expected %q, got %q", "/users/:name/photos/:id/*", s)
+	}
+	r := j.Match(mustReq("GET", "/users/carl/photos/1/large"))
+	if r == nil {
+		t.Fatal("expected a match")
+	}
+	ctx := r.Context()
+	if name := ctx.Value(pattern.Variable("name")); name != "carl" {
+		t.Errorf("name: expected %q, got %v", "carl", name)
+	}
+	if id := ctx.Value(pattern.Variable("id")); id != "1" {
+		t.Errorf("id: expected %q, got %v", "1", id)
+	}
+	if path := pattern.Path(ctx); path != "/large" {
+		t.Errorf("path: expected %q, got %q", "/large", path)
+	}
+	if !j.CoversPath(New("/users/carl/photos/1/")) {
+		t.Error("expected the joined pattern to cover its paths")
+	}
+
+	if New("/users/:name").JoinPath(New("/photos")) != nil {
+		t.Error("expected patterns without wildcards not to join")
+	}
+	if New("/users/*").JoinPath("/photos") != nil {
+		t.Error("expected patterns of a different type not to join")
+	}
+}
@@ -0,0 +1,58 @@
+package pat
+
+import (
+	"fmt"
+	"sort"
+
+	"goji.io/pattern"
+)
+
+/*
+Variables returns the names of the variables bound by this Pattern, in the
+order in which they appear in the pattern string.
+*/
+func (p *Pattern) Variables() []pattern.Variable {
+	names := make([]pattern.Variable, len(p.pats))
+	for _, pat := range p.pats {
+		names[pat.idx] = pat.name
+	}
+	return names
+}
+
+/*
+Reverse builds a path that this Pattern would match, binding each of the
+Pattern's variables to the corresponding value in the given map. Values are
+escaped so that matching the resulting path binds precisely the given values.
+For instance, reversing the pattern
+
+	/user/:name
+
+with the map {"name": "carl"} would return "/user/carl", and with the map
+{"name": "carl/photos"} would return "/user/carl%2Fphotos".
+
+Reverse returns an error if a variable has no (or an empty) value, or if the
+map contains a value for a variable that the Pattern does not bind. The path
+returned for a prefix wildcard pattern (e.g., "/user/*") ends with the slash
+that precedes the asterisk.
+*/
+func (p *Pattern) Reverse(vars map[string]string) (string, error) {
+	for name := range vars {
+		i := sort.Search(len(p.pats), func(i int) bool {
+			return p.pats[i].name >= pattern.Variable(name)
+		})
+		if i == len(p.pats) || p.pats[i].name != pattern.Variable(name) {
+			return "", fmt.Errorf("pat: %q has no variable %q", p.raw, name)
+		}
+	}
+
+	buf := []byte(p.literals[0])
+	for i, name := range p.Variables() {
+		value := vars[string(name)]
+		if value == "" {
+			return "", fmt.Errorf("pat: %q requires a value for variable %q", p.raw, name)
+		}
+		buf = append(buf, escape(value, p.breaks[i])...)
+		buf = append(buf, p.literals[i+1]...)
+	}
+	return string(buf), nil
+}
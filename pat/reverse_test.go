@@ -0,0 +1,72 @@
+package pat
+
+import (
+	"reflect"
+	"testing"
+
+	"goji.io/pattern"
+)
+
+var ReverseTests = []struct {
+	pat  string
+	vars map[string]string
+	path string
+	ok   bool
+}{
+	{"/", nil, "/", true},
+	{"/hello", nil, "/hello", true},
+	{"/hello", map[string]string{"name": "carl"}, "", false},
+	{"/user/:name", map[string]string{"name": "carl"}, "/user/carl", true},
+	{"/user/:name", map[string]string{"name": "carl/photos"}, "/user/carl%2Fphotos", true},
+	{"/user/:name", map[string]string{"name": "carl jackson"}, "/user/carl%20jackson", true},
+	{"/user/:name", map[string]string{"name": "100%"}, "/user/100%25", true},
+	{"/user/:name", map[string]string{"name": "data.json"}, "/user/data.json", true},
+	{"/user/:name", map[string]string{}, "", false},
+	{"/user/:name", map[string]string{"name": ""}, "", false},
+	{"/user/:name", map[string]string{"name": "carl", "color": "red"}, "", false},
+	{"/:file.:ext", map[string]string{"file": "data.tar", "ext": "gz"}, "/data%2Etar.gz", true},
+	{"/:name/:color", map[string]string{"name": "carl", "color": "red"}, "/carl/red", true},
+	{"/file;:version", map[string]string{"version": "1;2"}, "/file;1;2", true},
+	{"/user/*", nil, "/user/", true},
+	{"/user/:name/*", map[string]string{"name": "carl"}, "/user/carl/", true},
+}
+
+func TestReverse(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range ReverseTests {
+		pat := New(test.pat)
+		path, err := pat.Reverse(test.vars)
+		if (err == nil) != test.ok {
+			t.Errorf("[%q %v] err=%v, expected ok=%v", test.pat, test.vars, err, test.ok)
+		}
+		if path != test.path {
+			t.Errorf("[%q %v] path=%q, expected %q", test.pat, test.vars, path, test.path)
+		}
+		if err != nil {
+			continue
+		}
+
+		// Make sure the result round-trips
+		req := pat.Match(mustReq("GET", path))
+		if req == nil {
+			t.Errorf("[%q %v] did not match %q", test.pat, test.vars, path)
+			continue
+		}
+		for name, value := range test.vars {
+			if p := Param(req, name); p != value {
+				t.Errorf("[%q %v] %s=%q, expected %q", test.pat, test.vars, name, p, value)
+			}
+		}
+	}
+}
+
+func TestVariables(t *testing.T) {
+	t.Parallel()
+
+	pat := New("/:c/:a/:r/:l")
+	expected := []pattern.Variable{"c", "a", "r", "l"}
+	if vars := pat.Variables(); !reflect.DeepEqual(vars, expected) {
+		t.Errorf("expected %v, got %v", expected, vars)
+	}
+}
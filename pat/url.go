@@ -68,3 +68,52 @@ func unescape(s string) (string, error) {
 	}
 	return string(t), nil
 }
+
+// shouldEscape reports whether the given byte must be escaped when it appears in
+// the value of a variable that ends at the given break character. Break
+// characters must always be escaped, as must slashes (which end every
+// variable). Otherwise, we escape everything that is not permitted to appear in
+// a path segment.
+func shouldEscape(c, brk byte) bool {
+	if c == brk || c == '/' {
+		return true
+	}
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return false
+	}
+	switch c {
+	case '-', '.', '_', '~', '!', '$', '&', '\'', '(', ')', '*', '+', ',', ';', '=', ':', '@':
+		return false
+	}
+	return true
+}
+
+func escape(s string, brk byte) string {
+	n := 0
+	for i := 0; i < len(s); i++ {
+		if shouldEscape(s[i], brk) {
+			n++
+		}
+	}
+
+	if n == 0 {
+		return s
+	}
+
+	const upperhex = "0123456789ABCDEF"
+	t := make([]byte, len(s)+2*n)
+	j := 0
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; shouldEscape(c, brk) {
+			t[j] = '%'
+			t[j+1] = upperhex[c>>4]
+			t[j+2] = upperhex[c&15]
+			j += 3
+		} else {
+			t[j] = c
+			j++
+		}
+	}
+	return string(t)
+}
@@ -1,5 +1,7 @@
 package goji
 
+import "goji.io/pattern"
+
 // httpMethods is an internal interface for the HTTPMethods pattern
 // optimization. See the documentation on Pattern for more.
 type httpMethods interface {
@@ -11,3 +13,43 @@ type httpMethods interface {
 type pathPrefix interface {
 	PathPrefix() string
 }
+
+// pathSegments is an internal interface for the PathSegments pattern
+// optimization. See the documentation on Pattern for more.
+type pathSegments interface {
+	PathSegments() []pattern.Segment
+}
+
+// host is an internal interface for the Host pattern optimization. See the
+// documentation on Pattern for more.
+type host interface {
+	Host() string
+}
+
+// reverser is an internal interface for Patterns which are able to build the
+// paths they match. It is used to implement Mux.URL.
+type reverser interface {
+	Reverse(vars map[string]string) (string, error)
+}
+
+// variables is an internal interface for Patterns which can list the variables
+// they bind. It is used by Mux.URL to decide which variables belong to which
+// Pattern when building paths through nested Muxes.
+type variables interface {
+	Variables() []pattern.Variable
+}
+
+// pathRelations is an internal interface for Patterns which are able to compare
+// the set of paths they match to those of another Pattern. It is used to
+// implement Mux.Conflicts.
+type pathRelations interface {
+	CoversPath(other interface{}) bool
+	OverlapsPath(other interface{}) bool
+}
+
+// pathJoiner is an internal interface for Patterns which are able to combine
+// themselves with the Pattern matching the rest of the path. It is used to
+// compare the routes of Groups with other routes in Mux.Conflicts.
+type pathJoiner interface {
+	JoinPath(inner interface{}) interface{}
+}
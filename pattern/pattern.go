@@ -14,6 +14,8 @@ package pattern
 
 import (
 	"context"
+	"net/http"
+	"strings"
 
 	"goji.io/internal"
 )
@@ -65,3 +67,62 @@ the intended semantics of this path.
 func SetPath(ctx context.Context, path string) context.Context {
 	return context.WithValue(ctx, internal.Path, path)
 }
+
+/*
+SegmentKind identifies the type of a Segment.
+*/
+type SegmentKind int
+
+const (
+	// LiteralSegments match their Literal string exactly.
+	LiteralSegment SegmentKind = iota
+	// VariableSegments match any non-empty string which contains neither
+	// a slash ("/") nor the segment's Break character. They always
+	// consume as much of the path as they can.
+	VariableSegment
+	// WildcardSegments match any string at all, including the empty
+	// string. A WildcardSegment may only appear at the end of a list of
+	// Segments.
+	WildcardSegment
+)
+
+/*
+Segment describes one piece of the structure of the paths matched by a Pattern.
+Pattern authors can use lists of Segments to take advantage of Goji's
+PathSegments optimization; see the documentation for goji.Pattern for more.
+
+For instance, Goji's pat subpackage describes the pattern "/user/:name/*" using
+the following Segments:
+
+	[]pattern.Segment{
+		{Kind: pattern.LiteralSegment, Literal: "/user/"},
+		{Kind: pattern.VariableSegment, Break: '/'},
+		{Kind: pattern.LiteralSegment, Literal: "/"},
+		{Kind: pattern.WildcardSegment},
+	}
+*/
+type Segment struct {
+	Kind SegmentKind
+	// Literal is the string matched by LiteralSegments.
+	Literal string
+	// Break is the character which, in addition to the slash, ends a
+	// VariableSegment.
+	Break byte
+}
+
+/*
+Host returns the host name that the Goji router uses to perform the Host
+optimization: the request's Host, with any port (and trailing dot) removed, in
+lower case. Pattern authors who match against host names are encouraged to use
+this function in order to agree with the router about the host of each request.
+*/
+func Host(r *http.Request) string {
+	host := r.Host
+	if i := strings.LastIndexByte(host, ':'); i >= 0 && !strings.HasSuffix(host, "]") {
+		host = host[:i]
+	}
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	// Fully-qualified host names may end in a dot
+	host = strings.TrimSuffix(host, ".")
+	return strings.ToLower(host)
+}
@@ -36,3 +36,21 @@ func TestPathRoundTrip(t *testing.T) {
 		t.Errorf("expected empty path, got %q", path)
 	}
 }
+
+func TestHost(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct{ host, expected string }{
+		{"example.com", "example.com"},
+		{"Example.COM:8000", "example.com"},
+		{"example.com.", "example.com"},
+		{"[::1]:8000", "::1"},
+		{"[::1]", "::1"},
+	}
+	for _, test := range tests {
+		r := &http.Request{Host: test.host}
+		if host := Host(r); host != test.expected {
+			t.Errorf("Host(%q) = %q, expected %q", test.host, host, test.expected)
+		}
+	}
+}
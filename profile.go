@@ -0,0 +1,43 @@
+package goji
+
+import (
+	"context"
+	"fmt"
+
+	"goji.io/internal"
+)
+
+/*
+ProfileLabels controls whether the Mux runs the handlers it dispatches to with
+runtime/pprof labels identifying the route, so that CPU and goroutine profiles
+can be broken down by endpoint.
+
+When enabled, handlers are run using pprof.Do with the labels "goji_route", set
+to the full route template matched so far (for instance, "/users/:name"; see
+middleware.Template), and "goji_method", set to the request's method. Since the
+route template includes the Patterns matched by every enclosing Mux, a SubMux
+with ProfileLabels enabled refines the label set by its parent (for instance,
+from "/users/*" to "/users/:name/photos") instead of replacing it with its own
+Pattern.
+
+Only the handler the request is dispatched to runs with the labels: middleware,
+and 404 and 405 responses, do not. ProfileLabels is disabled by default, and has
+no effect on versions of Go prior to 1.9. It is not safe to call ProfileLabels
+concurrently with requests unless the Mux is in copy-on-write mode.
+*/
+func (m *Mux) ProfileLabels(enabled bool) {
+	m.update(func(m *Mux) {
+		m.profileLabels = enabled
+		m.buildChain()
+	})
+}
+
+// template returns the full route template matched in the given context.
+func template(ctx context.Context) string {
+	chain, _ := ctx.Value(internal.Patterns).([]Pattern)
+	parts := make([]string, len(chain))
+	for i, p := range chain {
+		parts[i] = fmt.Sprint(p)
+	}
+	return internal.Template(parts)
+}
@@ -0,0 +1,10 @@
+// +build !go1.9
+
+package goji
+
+import "net/http"
+
+// Profiler labels were introduced in Go 1.9.
+func serveLabeled(h http.Handler, w http.ResponseWriter, r *http.Request) {
+	h.ServeHTTP(w, r)
+}
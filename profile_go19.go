@@ -0,0 +1,19 @@
+// +build go1.9
+
+package goji
+
+import (
+	"context"
+	"net/http"
+	"runtime/pprof"
+)
+
+// serveLabeled serves the request using h, with profiler labels identifying
+// the route. See ProfileLabels.
+func serveLabeled(h http.Handler, w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	labels := pprof.Labels("goji_route", template(ctx), "goji_method", r.Method)
+	pprof.Do(ctx, labels, func(ctx context.Context) {
+		h.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
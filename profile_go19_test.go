@@ -0,0 +1,53 @@
+// +build go1.9
+
+package goji
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"runtime/pprof"
+	"testing"
+
+	"goji.io/pat"
+)
+
+func TestProfileLabels(t *testing.T) {
+	t.Parallel()
+
+	ch := make(chan string, 10)
+	labels := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			route, _ := pprof.Label(r.Context(), "goji_route")
+			method, _ := pprof.Label(r.Context(), "goji_method")
+			ch <- name + " " + method + " " + route
+		}
+	}
+	wrap := func(name string, h http.Handler) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			labels(name)(w, r)
+			h.ServeHTTP(w, r)
+		}
+	}
+
+	root := NewMux()
+	root.ProfileLabels(true)
+	users := SubMux()
+	users.ProfileLabels(true)
+	users.Handle(pat.Get("/:name/photos"), labels("photos"))
+	root.Handle(pat.New("/users/*"), wrap("users", users))
+	plain := SubMux()
+	plain.Handle(pat.Get("/:id"), labels("albums"))
+	root.Handle(pat.New("/albums/*"), plain)
+	root.Use(func(h http.Handler) http.Handler {
+		return wrap("middleware", h)
+	})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/users/carl/photos", nil)
+	root.ServeHTTP(w, r)
+	expectSequence(t, ch, "middleware  ", "users GET /users/*", "photos GET /users/:name/photos")
+
+	r, _ = http.NewRequest("GET", "/albums/1", nil)
+	root.ServeHTTP(w, r)
+	expectSequence(t, ch, "middleware  ", "albums GET /albums/*")
+}
@@ -0,0 +1,143 @@
+package goji
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"strings"
+
+	"goji.io/internal"
+)
+
+/*
+RedirectPolicy is a set of rules the Mux uses to redirect requests that did not
+match any route to a canonical path that would have. See Mux.Redirect.
+*/
+type RedirectPolicy uint
+
+const (
+	/*
+		RedirectTrailingSlash redirects requests for "/hello/" to "/hello"
+		(and vice versa) when the path with its trailing slash toggled would
+		have matched a route.
+	*/
+	RedirectTrailingSlash RedirectPolicy = 1 << iota
+	/*
+		RedirectCleanPath redirects requests whose path contains empty
+		segments, "." segments, or ".." segments to the path returned by
+		path.Clean (with any trailing slash preserved) when that path would
+		have matched a route.
+	*/
+	RedirectCleanPath
+)
+
+/*
+Redirect sets the Mux's redirect policy, which is empty by default.
+
+When a request matches no route, the Mux consults its redirect policy: for each
+alternate path the policy allows, in the order cleaned, slash-toggled, and
+cleaned then slash-toggled, the Mux routes the request again as if it had been
+made for that path. If any alternate path is matched by a route, the Mux
+responds with a redirect to it instead of a 404 or 405. The redirect preserves
+the request's query string, and is a 301 Moved Permanently for GET and HEAD
+requests and a 308 Permanent Redirect (which instructs clients to preserve the
+method and body) otherwise.
+
+Paths are manipulated in their escaped form, as stored by the root Mux. When
+used on a SubMux, only the part of the path the SubMux routes on is rewritten.
+Redirects are generated at the end of the middleware stack, and so are visible
+to middleware in the same way that ordinary routes are. It is not safe to call
+Redirect concurrently with requests unless the Mux is in copy-on-write mode.
+*/
+func (m *Mux) Redirect(policy RedirectPolicy) {
+	m.update(func(m *Mux) {
+		m.redirect = policy
+	})
+}
+
+type redirectKeyType struct{}
+
+// redirectKey is the context key under which the Mux stores the location to
+// which an unmatched request should be redirected.
+var redirectKey = redirectKeyType{}
+
+// redirectTo attaches a redirect location to r, which was not matched by any
+// route, if the Mux's redirect policy finds one.
+func (m *Mux) redirectTo(r *http.Request) *http.Request {
+	ctx := r.Context()
+	p, ok := ctx.Value(internal.Path).(string)
+	if !ok {
+		return r
+	}
+	full := r.URL.EscapedPath()
+	if !strings.HasSuffix(full, p) {
+		return r
+	}
+	prefix := full[:len(full)-len(p)]
+
+	for _, alt := range m.redirect.alternates(p) {
+		loc := prefix + alt
+		// A location starting with two slashes would be interpreted by
+		// clients as a host name.
+		if alt == p || !strings.HasPrefix(loc, "/") || strings.HasPrefix(loc, "//") {
+			continue
+		}
+		actx := context.WithValue(ctx, internal.Path, alt)
+		if m.router.route(r.WithContext(actx)).Context().Value(internal.Handler) == nil {
+			continue
+		}
+		if r.URL.RawQuery != "" {
+			loc += "?" + r.URL.RawQuery
+		}
+		return r.WithContext(context.WithValue(ctx, redirectKey, loc))
+	}
+	return r
+}
+
+// alternates returns the paths the policy allows redirecting p to, in order of
+// preference.
+func (policy RedirectPolicy) alternates(p string) []string {
+	var alts []string
+	clean := p
+	if policy&RedirectCleanPath != 0 {
+		clean = cleanPath(p)
+		alts = append(alts, clean)
+	}
+	if policy&RedirectTrailingSlash != 0 {
+		alts = append(alts, toggleSlash(p))
+		if clean != p {
+			alts = append(alts, toggleSlash(clean))
+		}
+	}
+	return alts
+}
+
+// cleanPath is path.Clean, but preserves trailing slashes.
+func cleanPath(p string) string {
+	if p == "" {
+		return p
+	}
+	c := path.Clean(p)
+	if strings.HasSuffix(p, "/") && c != "/" {
+		c += "/"
+	}
+	return c
+}
+
+// toggleSlash adds a trailing slash to p, or removes the one it has.
+func toggleSlash(p string) string {
+	if strings.HasSuffix(p, "/") {
+		return p[:len(p)-1]
+	}
+	return p + "/"
+}
+
+// redirect replies to the request with a permanent redirect to loc.
+func redirect(w http.ResponseWriter, r *http.Request, loc string) {
+	code := http.StatusPermanentRedirect
+	if r.Method == "GET" || r.Method == "HEAD" {
+		code = http.StatusMovedPermanently
+	}
+	w.Header().Set("Location", loc)
+	w.WriteHeader(code)
+}
@@ -0,0 +1,78 @@
+package goji
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"goji.io/pat"
+)
+
+var RedirectTests = []struct {
+	policy       RedirectPolicy
+	method, path string
+	code         int
+	location     string
+}{
+	{0, "GET", "/a/", 404, ""},
+	{RedirectTrailingSlash, "GET", "/a/", 301, "/a"},
+	{RedirectTrailingSlash, "GET", "/b", 301, "/b/"},
+	{RedirectTrailingSlash, "GET", "/a/?x=1&y", 301, "/a?x=1&y"},
+	{RedirectTrailingSlash, "POST", "/b", 308, "/b/"},
+	{RedirectTrailingSlash, "POST", "/a/", 404, ""},
+	{RedirectTrailingSlash, "GET", "/c", 404, ""},
+	{RedirectTrailingSlash, "GET", "//a/", 404, ""},
+	{RedirectTrailingSlash, "GET", "/b/./", 404, ""},
+	{RedirectCleanPath, "GET", "/b/./", 301, "/b/"},
+	{RedirectCleanPath, "GET", "/x/../a", 301, "/a"},
+	{RedirectCleanPath, "GET", "/./a", 301, "/a"},
+	{RedirectCleanPath, "GET", "/x/../a/", 404, ""},
+	{RedirectCleanPath | RedirectTrailingSlash, "GET", "/x/../a/", 301, "/a"},
+	{RedirectCleanPath, "GET", "/d%2F/../e", 301, "/e"},
+}
+
+func TestRedirect(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range RedirectTests {
+		m := NewMux()
+		m.Handle(pat.Get("/a"), intHandler(0))
+		m.Handle(pat.New("/b/"), intHandler(1))
+		m.Handle(pat.Get("/e"), intHandler(2))
+		m.Redirect(test.policy)
+
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest(test.method, test.path, nil)
+		if err != nil {
+			panic(err)
+		}
+		m.ServeHTTP(w, r)
+
+		if w.Code != test.code {
+			t.Errorf("[%d %s %s] status: expected %d, got %d", test.policy, test.method, test.path, test.code, w.Code)
+		}
+		if loc := w.Header().Get("Location"); loc != test.location {
+			t.Errorf("[%d %s %s] Location: expected %q, got %q", test.policy, test.method, test.path, test.location, loc)
+		}
+	}
+}
+
+func TestRedirectSubMux(t *testing.T) {
+	t.Parallel()
+
+	m := NewMux()
+	sub := SubMux()
+	sub.Handle(pat.Get("/b"), intHandler(0))
+	sub.Redirect(RedirectTrailingSlash)
+	m.Handle(pat.New("/a/*"), sub)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/a/b/?q", nil)
+	m.ServeHTTP(w, r)
+	if w.Code != 301 {
+		t.Errorf("status: expected %d, got %d", 301, w.Code)
+	}
+	if loc := w.Header().Get("Location"); loc != "/a/b?q" {
+		t.Errorf("Location: expected %q, got %q", "/a/b?q", loc)
+	}
+}
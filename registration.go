@@ -0,0 +1,152 @@
+package goji
+
+import "net/http"
+
+/*
+Registration is a handle to a route that was added to a Mux with Handle (or one
+of its variants). It can be used to remove the route from the Mux, or to replace
+the route's Handler, without reconstructing the Mux.
+
+Like other forms of configuration, it is not safe to use a Registration
+concurrently with requests unless its Mux is in copy-on-write mode (see
+CopyOnWrite).
+*/
+type Registration struct {
+	mux     *Mux
+	group   *Group
+	name    string
+	pattern Pattern
+}
+
+// entry holds the configuration of a single route. A Mux's entries parallel the
+// routes held by its router, which only knows about the route's compiled
+// http.Handler.
+type entry struct {
+	reg        *Registration
+	handler    http.Handler
+	middleware []func(http.Handler) http.Handler
+	// group is the middleware of the route's Group (and its parents),
+	// outermost first.
+	group []func(http.Handler) http.Handler
+}
+
+// compile pre-compiles the route's middleware stack, in the same manner as
+// buildChain. Group middleware wraps the route's own middleware.
+func (e entry) compile() http.Handler {
+	h := e.handler
+	for i := len(e.middleware) - 1; i >= 0; i-- {
+		h = e.middleware[i](h)
+	}
+	for i := len(e.group) - 1; i >= 0; i-- {
+		h = e.group[i](h)
+	}
+	return h
+}
+
+func (m *Mux) register(g *Group, name string, p Pattern, h http.Handler) *Registration {
+	reg := &Registration{mux: m, group: g, name: name, pattern: p}
+	m.update(func(m *Mux) {
+		if m.strict {
+			routes := m.routes()
+			routes = append(routes, Route{p, h, len(routes)})
+			if cs := conflicts(routes, len(routes)-1); len(cs) > 0 {
+				panic("goji: " + cs[0].String())
+			}
+		}
+		if name != "" {
+			if _, ok := m.names[name]; ok {
+				panic("goji: multiple registrations for route " + name)
+			}
+			if m.names == nil {
+				m.names = make(map[string]*Registration)
+			}
+			m.names[name] = reg
+		}
+		e := entry{reg: reg, handler: h, group: g.chain()}
+		m.entries = append(m.entries, e)
+		m.router.add(p, e.compile())
+	})
+	return reg
+}
+
+// index returns the position of the route in its Mux's routing order, or -1 if
+// the route has been removed.
+func (reg *Registration) index(m *Mux) int {
+	for i, e := range m.entries {
+		if e.reg == reg {
+			return i
+		}
+	}
+	return -1
+}
+
+/*
+Pattern returns the Pattern the route was registered with.
+*/
+func (reg *Registration) Pattern() Pattern {
+	return reg.pattern
+}
+
+/*
+Remove removes the route from its Mux. Subsequent requests are routed as if the
+route had never been added, and the relative order of the remaining routes is
+unchanged. Calling Remove on a route that has already been removed has no
+effect.
+*/
+func (reg *Registration) Remove() {
+	reg.mux.update(func(m *Mux) {
+		i := reg.index(m)
+		if i < 0 {
+			return
+		}
+		m.entries = append(m.entries[:i], m.entries[i+1:]...)
+		if reg.name != "" {
+			delete(m.names, reg.name)
+		}
+		m.router.remove(i)
+	})
+}
+
+/*
+SetHandler replaces the http.Handler that requests matching the route are
+dispatched to. The route keeps its position in the routing order, as well as any
+middleware added with Use. Calling SetHandler on a route that has been removed
+has no effect.
+*/
+func (reg *Registration) SetHandler(h http.Handler) {
+	reg.mux.update(func(m *Mux) {
+		if i := reg.index(m); i >= 0 {
+			m.entries[i].handler = h
+			m.router.setHandler(i, m.entries[i].compile())
+		}
+	})
+}
+
+/*
+Use appends a middleware to the route's middleware stack. Route middleware
+behaves exactly like middleware added to a Mux with Mux.Use, except that it only
+applies to requests that are routed to this route. This makes it a convenient
+way to, for example, require authentication for only a handful of endpoints:
+
+	mux.Handle(pat.Get("/admin"), admin).Use(requireAdmin)
+
+Route middleware is called after the Mux's middleware stack, just before the
+route's Handler. Like the Mux's middleware stack, the route's middleware stack
+is compiled ahead of time, so adding route middleware does not increase the
+per-request cost of routing. Note that the Handler returned by
+middleware.Handler is the route's compiled middleware stack, and not the
+Handler the route was registered with.
+
+Use returns the Registration, so that calls can be chained. Calling Use on a
+route that has been removed has no effect.
+*/
+func (reg *Registration) Use(middleware func(http.Handler) http.Handler) *Registration {
+	reg.mux.update(func(m *Mux) {
+		if i := reg.index(m); i >= 0 {
+			e := &m.entries[i]
+			e.middleware = append(e.middleware[:len(e.middleware):len(e.middleware)], middleware)
+			m.router.setHandler(i, e.compile())
+		}
+	})
+	return reg
+}
@@ -3,25 +3,64 @@ package goji
 import (
 	"context"
 	"net/http"
+	"sort"
+	"strings"
 
 	"goji.io/internal"
 )
 
 type match struct {
 	context.Context
-	p Pattern
-	h http.Handler
+	p       Pattern
+	h       http.Handler
+	allowed map[string]struct{}
 }
 
 func (m match) Value(key interface{}) interface{} {
 	switch key {
 	case internal.Pattern:
-		return m.p
+		return reported(m.p)
+	case internal.Patterns:
+		return m.patterns()
 	case internal.Handler:
 		return m.h
+	case allowedKey:
+		return m.allowed
 	default:
 		return m.Context.Value(key)
 	}
 }
 
+// patterns returns the Patterns matched by this and every enclosing Mux,
+// outermost first, expanding the prefixes of Groups.
+func (m match) patterns() []Pattern {
+	chain, _ := m.Context.Value(internal.Patterns).([]Pattern)
+	if m.p == nil {
+		return chain
+	}
+	chain = chain[:len(chain):len(chain)]
+	if g, ok := m.p.(*groupPattern); ok {
+		chain = append(chain, g.prefixes...)
+		return append(chain, g.inner)
+	}
+	return append(chain, m.p)
+}
+
 var _ context.Context = match{}
+
+type allowedKeyType struct{}
+
+// allowedKey is the context key used to store the set of HTTP methods that
+// would have been routed had the request used a different method. It is only
+// set when routing fails.
+var allowedKey = allowedKeyType{}
+
+// allowHeader formats a set of HTTP methods for use in an Allow header.
+func allowHeader(methods map[string]struct{}) string {
+	list := make([]string, 0, len(methods))
+	for method := range methods {
+		list = append(list, method)
+	}
+	sort.Strings(list)
+	return strings.Join(list, ", ")
+}
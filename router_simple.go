@@ -2,7 +2,11 @@
 
 package goji
 
-import "net/http"
+import (
+	"net/http"
+
+	"goji.io/internal"
+)
 
 /*
 This is the simplest correct router implementation for Goji.
@@ -19,9 +23,32 @@ func (rt *router) add(p Pattern, h http.Handler) {
 	*rt = append(*rt, route{p, h})
 }
 
+func (rt *router) remove(idx int) {
+	*rt = append((*rt)[:idx], (*rt)[idx+1:]...)
+}
+
+func (rt *router) setHandler(idx int, h http.Handler) {
+	(*rt)[idx].Handler = h
+}
+
+func (rt *router) clone() router {
+	return append(router(nil), *rt...)
+}
+
 func (rt *router) route(r *http.Request) *http.Request {
+	return rt.trace(r, nil)
+}
+
+// trace routes the request, recording the routes it tries in t.
+func (rt *router) trace(r *http.Request, t *Trace) *http.Request {
+	var path string
+	if t != nil {
+		path, _ = r.Context().Value(internal.Path).(string)
+	}
 	for _, route := range *rt {
-		if r2 := route.Match(r); r2 != nil {
+		r2 := route.Match(r)
+		t.record(path, route.Pattern, r2 != nil)
+		if r2 != nil {
 			return r2.WithContext(&match{
 				Context: r2.Context(),
 				p:       route.Pattern,
@@ -29,5 +56,34 @@ func (rt *router) route(r *http.Request) *http.Request {
 			})
 		}
 	}
-	return r.WithContext(&match{Context: r.Context()})
+	return r.WithContext(&match{Context: r.Context(), allowed: rt.allowed(r)})
+}
+
+// allowed returns the set of HTTP methods, other than the request's own, for
+// which some route would have matched the request, or nil if there are none.
+func (rt *router) allowed(r *http.Request) map[string]struct{} {
+	var methods map[string]struct{}
+	var r2 *http.Request
+	for _, route := range *rt {
+		hm, ok := route.Pattern.(httpMethods)
+		if !ok {
+			continue
+		}
+		for method := range hm.HTTPMethods() {
+			if _, ok := methods[method]; ok || method == r.Method {
+				continue
+			}
+			if r2 == nil {
+				r2 = r.WithContext(r.Context())
+			}
+			r2.Method = method
+			if route.Match(r2) != nil {
+				if methods == nil {
+					methods = make(map[string]struct{})
+				}
+				methods[method] = struct{}{}
+			}
+		}
+	}
+	return methods
 }
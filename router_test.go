@@ -151,3 +151,113 @@ func TestRouterContextPropagation(t *testing.T) {
 		t.Fatalf("routed request didn't include correct key from pattern: %q", hello)
 	}
 }
+
+var AllowedRoutes = []testPattern{
+	testPattern{methods: []string{"GET"}, prefix: "/a"},
+	testPattern{methods: []string{"POST", "PUT"}, prefix: "/a"},
+	testPattern{methods: []string{"DELETE"}, prefix: "/b"},
+	testPattern{methods: nil, prefix: "/c"},
+}
+
+var AllowedTests = []struct {
+	method, path string
+	allowed      string
+}{
+	{"GET", "/a", ""},
+	{"DELETE", "/a", "GET, POST, PUT"},
+	{"PUT", "/ab", ""},
+	{"GET", "/b", "DELETE"},
+	{"POST", "/c", ""},
+	{"GET", "/d", ""},
+}
+
+func TestRouterAllowed(t *testing.T) {
+	t.Parallel()
+
+	var rt router
+	mark := new(int)
+	for i, p := range AllowedRoutes {
+		p.index = i
+		p.mark = mark
+		rt.add(p, intHandler(i))
+	}
+
+	for _, test := range AllowedTests {
+		r, err := http.NewRequest(test.method, test.path, nil)
+		if err != nil {
+			panic(err)
+		}
+		ctx := context.WithValue(context.Background(), internal.Path, test.path)
+		r = rt.route(r.WithContext(ctx))
+
+		allowed, _ := r.Context().Value(allowedKey).(map[string]struct{})
+		if header := allowHeader(allowed); header != test.allowed {
+			t.Errorf("[%s %s] allowed=%q, expected %q", test.method, test.path, header, test.allowed)
+		}
+	}
+
+	// Methods are only allowed if the Patterns that match them advertise
+	// them, even if some other route asks for the same method.
+	rt = router{}
+	rt.add(postPattern("/x"), intHandler(0))
+	rt.add(testPattern{index: 1, mark: mark, methods: []string{"POST"}, prefix: "/y"}, intHandler(1))
+	r, _ := http.NewRequest("GET", "/x", nil)
+	r = rt.route(r.WithContext(context.WithValue(context.Background(), internal.Path, "/x")))
+	if allowed, _ := r.Context().Value(allowedKey).(map[string]struct{}); allowed != nil {
+		t.Errorf("[GET /x] expected no allowed methods, got %v", allowed)
+	}
+}
+
+// postPattern matches POST requests for a single path, without advertising its
+// method using the HTTPMethods optimization.
+type postPattern string
+
+func (p postPattern) Match(r *http.Request) *http.Request {
+	if r.Method == "POST" && r.Context().Value(internal.Path) == string(p) {
+		return r
+	}
+	return nil
+}
+
+func TestRouterRemove(t *testing.T) {
+	t.Parallel()
+
+	mark := new(int)
+	for k := range TestRoutes {
+		// rt has every route, but route k is removed afterwards, while
+		// expected never had route k to begin with.
+		var rt, expected router
+		for i, p := range TestRoutes {
+			p.index = i
+			p.mark = mark
+			rt.add(p, intHandler(i))
+			if i != k {
+				expected.add(p, intHandler(i))
+			}
+		}
+		rt.remove(k)
+
+		for _, test := range RouterTests {
+			r, err := http.NewRequest(test.method, test.path, nil)
+			if err != nil {
+				panic(err)
+			}
+			ctx := context.WithValue(context.Background(), internal.Path, test.path)
+			r = r.WithContext(ctx)
+
+			for m := 0; m < len(TestRoutes); m++ {
+				*mark = m
+				actual := rt.route(r).Context()
+				want := expected.route(r).Context()
+				if h1, h2 := actual.Value(internal.Handler), want.Value(internal.Handler); h1 != h2 {
+					t.Errorf("[remove %d, %s %s, mark %d] expected %v, got %v", k, test.method, test.path, m, h2, h1)
+				}
+				a1, _ := actual.Value(allowedKey).(map[string]struct{})
+				a2, _ := want.Value(allowedKey).(map[string]struct{})
+				if h1, h2 := allowHeader(a1), allowHeader(a2); h1 != h2 {
+					t.Errorf("[remove %d, %s %s, mark %d] expected allowed %q, got %q", k, test.method, test.path, m, h2, h1)
+				}
+			}
+		}
+	}
+}
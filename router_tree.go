@@ -0,0 +1,214 @@
+// +build !goji_router_simple
+
+package goji
+
+import (
+	"sort"
+	"strings"
+
+	"goji.io/pattern"
+)
+
+/*
+The segment tree indexes routes whose Patterns implement the PathSegments
+optimization. Unlike the trie, which only knows about path prefixes, the tree
+follows routes through their variables, so that (for instance) the path
+"/api/acme/orders/42" reaches only those routes which could have matched it,
+regardless of how many other routes share the "/api/" prefix.
+
+Literal edges are compressed in the same way the trie's are. Variable edges are
+labeled with their break character, and are followed by consuming the longest
+possible run of non-break characters. Since a path may be able to follow both a
+literal edge and a variable edge out of a node, lookups may visit several
+branches of the tree: the route indices they find are then sorted so that the
+router can preserve first-match semantics.
+*/
+type segNode struct {
+	// routes end at this node: they match when the path is exhausted.
+	routes []int
+	// wildcards match any (possibly empty) remainder of the path.
+	wildcards []int
+	literals  []segChild
+	variables []segChild
+}
+
+type segChild struct {
+	// For literal edges, the (compressed) literal. For variable edges, the
+	// break character.
+	prefix string
+	brk    byte
+	node   *segNode
+}
+
+func (sn *segNode) add(segs []pattern.Segment, idx int) {
+	for len(segs) > 0 {
+		seg := segs[0]
+		segs = segs[1:]
+		switch seg.Kind {
+		case pattern.LiteralSegment:
+			sn = sn.literal(seg.Literal)
+		case pattern.VariableSegment:
+			sn = sn.variable(seg.Break)
+		case pattern.WildcardSegment:
+			sn.wildcards = append(sn.wildcards, idx)
+			return
+		}
+	}
+	sn.routes = append(sn.routes, idx)
+}
+
+// literal returns the node reached by following the given literal from this
+// one, creating (and splitting) edges as necessary.
+func (sn *segNode) literal(prefix string) *segNode {
+	for prefix != "" {
+		ch := prefix[0]
+		i := sort.Search(len(sn.literals), func(i int) bool {
+			return ch <= sn.literals[i].prefix[0]
+		})
+
+		if i == len(sn.literals) || ch != sn.literals[i].prefix[0] {
+			node := new(segNode)
+			sn.literals = append(sn.literals, segChild{prefix: prefix, node: node})
+			sort.Sort(bySegPrefix(sn.literals))
+			return node
+		}
+
+		lp := longestPrefix(prefix, sn.literals[i].prefix)
+		if lp != sn.literals[i].prefix {
+			split := &segNode{literals: []segChild{
+				{prefix: sn.literals[i].prefix[len(lp):], node: sn.literals[i].node},
+			}}
+			sn.literals[i] = segChild{prefix: lp, node: split}
+		}
+		prefix = prefix[len(lp):]
+		sn = sn.literals[i].node
+	}
+	return sn
+}
+
+func (sn *segNode) variable(brk byte) *segNode {
+	for _, ch := range sn.variables {
+		if ch.brk == brk {
+			return ch.node
+		}
+	}
+	node := new(segNode)
+	sn.variables = append(sn.variables, segChild{brk: brk, node: node})
+	return node
+}
+
+// lookup appends the indices of all routes whose segments can produce the given
+// path to out, in no particular order.
+func (sn *segNode) lookup(path string, out []int) []int {
+	out = append(out, sn.wildcards...)
+	if path == "" {
+		return append(out, sn.routes...)
+	}
+
+	ch := path[0]
+	i := sort.Search(len(sn.literals), func(i int) bool {
+		return ch <= sn.literals[i].prefix[0]
+	})
+	if i < len(sn.literals) && strings.HasPrefix(path, sn.literals[i].prefix) {
+		out = sn.literals[i].node.lookup(path[len(sn.literals[i].prefix):], out)
+	}
+
+	for _, v := range sn.variables {
+		m := 0
+		for ; m < len(path); m++ {
+			if path[m] == v.brk || path[m] == '/' {
+				break
+			}
+		}
+		if m > 0 {
+			out = v.node.lookup(path[m:], out)
+		}
+	}
+	return out
+}
+
+// remove removes the route with the given index from the tree, renumbering the
+// routes that follow it.
+func (sn *segNode) remove(idx int) {
+	sn.routes = renumber(sn.routes, idx)
+	sn.wildcards = renumber(sn.wildcards, idx)
+	for _, ch := range sn.literals {
+		ch.node.remove(idx)
+	}
+	for _, ch := range sn.variables {
+		ch.node.remove(idx)
+	}
+}
+
+func (sn *segNode) clone() *segNode {
+	clone := &segNode{
+		routes:    append([]int(nil), sn.routes...),
+		wildcards: append([]int(nil), sn.wildcards...),
+		literals:  append([]segChild(nil), sn.literals...),
+		variables: append([]segChild(nil), sn.variables...),
+	}
+	for i := range clone.literals {
+		clone.literals[i].node = sn.literals[i].node.clone()
+	}
+	for i := range clone.variables {
+		clone.variables[i].node = sn.variables[i].node.clone()
+	}
+	return clone
+}
+
+type bySegPrefix []segChild
+
+func (b bySegPrefix) Len() int {
+	return len(b)
+}
+func (b bySegPrefix) Less(i, j int) bool {
+	return b[i].prefix < b[j].prefix
+}
+func (b bySegPrefix) Swap(i, j int) {
+	b[i], b[j] = b[j], b[i]
+}
+
+// renumber removes idx from the given list of route indices, and decrements
+// every index that follows it.
+func renumber(routes []int, idx int) []int {
+	out := make([]int, 0, len(routes))
+	for _, i := range routes {
+		if i > idx {
+			out = append(out, i-1)
+		} else if i < idx {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// merge merges two sorted lists of route indices. It avoids allocating in the
+// common case where one of the lists is empty.
+func merge(a, b []int) []int {
+	if len(b) == 0 {
+		return a
+	} else if len(a) == 0 {
+		return b
+	}
+
+	out := make([]int, 0, len(a)+len(b))
+	for len(a) > 0 && len(b) > 0 {
+		if a[0] < b[0] {
+			out, a = append(out, a[0]), a[1:]
+		} else {
+			out, b = append(out, b[0]), b[1:]
+		}
+	}
+	out = append(out, a...)
+	return append(out, b...)
+}
+
+// sortInts is an insertion sort. Lookups typically return very few routes, and
+// unlike sort.Ints it does not allocate.
+func sortInts(a []int) {
+	for i := 1; i < len(a); i++ {
+		for j := i; j > 0 && a[j] < a[j-1]; j-- {
+			a[j], a[j-1] = a[j-1], a[j]
+		}
+	}
+}
@@ -0,0 +1,215 @@
+// +build !goji_router_simple
+
+package goji
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"goji.io/internal"
+	"goji.io/pattern"
+)
+
+// segPattern is a Pattern described by a string in which ":" stands for a
+// variable (broken by the character that follows it) and a trailing "*" stands
+// for a wildcard.
+type segPattern struct {
+	methods []string
+	pat     string
+}
+
+func (s segPattern) PathSegments() []pattern.Segment {
+	var segs []pattern.Segment
+	lit := ""
+	for i := 0; i < len(s.pat); i++ {
+		switch c := s.pat[i]; {
+		case c == ':':
+			if lit != "" {
+				segs = append(segs, pattern.Segment{Kind: pattern.LiteralSegment, Literal: lit})
+				lit = ""
+			}
+			brk := byte('/')
+			if i+1 < len(s.pat) && s.pat[i+1] != '*' {
+				brk = s.pat[i+1]
+			}
+			segs = append(segs, pattern.Segment{Kind: pattern.VariableSegment, Break: brk})
+		case c == '*' && i == len(s.pat)-1:
+			if lit != "" {
+				segs = append(segs, pattern.Segment{Kind: pattern.LiteralSegment, Literal: lit})
+				lit = ""
+			}
+			segs = append(segs, pattern.Segment{Kind: pattern.WildcardSegment})
+		default:
+			lit += string(c)
+		}
+	}
+	if lit != "" {
+		segs = append(segs, pattern.Segment{Kind: pattern.LiteralSegment, Literal: lit})
+	}
+	return segs
+}
+
+func (s segPattern) HTTPMethods() map[string]struct{} {
+	return testPattern{methods: s.methods}.HTTPMethods()
+}
+
+func (s segPattern) Match(r *http.Request) *http.Request {
+	if s.methods != nil {
+		if _, ok := s.HTTPMethods()[r.Method]; !ok {
+			return nil
+		}
+	}
+	path := r.Context().Value(internal.Path).(string)
+	for _, seg := range s.PathSegments() {
+		switch seg.Kind {
+		case pattern.LiteralSegment:
+			if len(path) < len(seg.Literal) || path[:len(seg.Literal)] != seg.Literal {
+				return nil
+			}
+			path = path[len(seg.Literal):]
+		case pattern.VariableSegment:
+			m := 0
+			for m < len(path) && path[m] != '/' && path[m] != seg.Break {
+				m++
+			}
+			if m == 0 {
+				return nil
+			}
+			path = path[m:]
+		case pattern.WildcardSegment:
+			return r
+		}
+	}
+	if path != "" {
+		return nil
+	}
+	return r
+}
+
+var TreeRoutes = []Pattern{
+	segPattern{nil, "/api/:/orders/:"},
+	segPattern{[]string{"GET"}, "/api/:/orders/new"},
+	segPattern{[]string{"POST"}, "/api/:/orders/"},
+	testPattern{prefix: "/api/acme/"},
+	segPattern{nil, "/api/:/users/:.:"},
+	segPattern{[]string{"GET"}, "/api/*"},
+	segPattern{nil, "/:.json"},
+	segPattern{nil, "/:"},
+	segPattern{[]string{"PUT"}, "/api/:/*"},
+	testPattern{methods: []string{"DELETE"}, prefix: "/"},
+	segPattern{nil, "/a:/b"},
+	segPattern{nil, "/*"},
+}
+
+var TreeTests = []struct {
+	method, path string
+}{
+	{"GET", "/"},
+	{"GET", "/api"},
+	{"GET", "/api/"},
+	{"GET", "/api/acme/orders/42"},
+	{"GET", "/api/other/orders/42"},
+	{"GET", "/api/other/orders/new"},
+	{"PUT", "/api/other/orders/new"},
+	{"POST", "/api/other/orders/"},
+	{"POST", "/api/acme/orders/"},
+	{"GET", "/api/other/users/carl.json"},
+	{"GET", "/api/other/users/carl"},
+	{"PUT", "/api/other/users/carl"},
+	{"DELETE", "/api/other/users/carl"},
+	{"GET", "/data.json"},
+	{"GET", "/data.xml"},
+	{"GET", "/data"},
+	{"GET", "/abc/b"},
+	{"GET", "/a/b"},
+}
+
+func TestRouterTree(t *testing.T) {
+	t.Parallel()
+
+	mark := new(int)
+	var rt router
+	// Start with a route that matches everything, and then remove it to
+	// ensure that the tree renumbers routes correctly.
+	rt.add(segPattern{nil, "/*"}, intHandler(-1))
+	for i, p := range TreeRoutes {
+		if tp, ok := p.(testPattern); ok {
+			tp.mark = mark
+			p = tp
+		}
+		TreeRoutes[i] = p
+		rt.add(p, intHandler(i))
+	}
+	rt.remove(0)
+
+	for _, test := range TreeTests {
+		r, err := http.NewRequest(test.method, test.path, nil)
+		if err != nil {
+			panic(err)
+		}
+		ctx := context.WithValue(context.Background(), internal.Path, test.path)
+		r = r.WithContext(ctx)
+
+		expected := -1
+		for i, p := range TreeRoutes {
+			if p.Match(r) != nil {
+				expected = i
+				break
+			}
+		}
+
+		actual := -1
+		if h := rt.route(r).Context().Value(internal.Handler); h != nil {
+			actual = int(h.(intHandler))
+		}
+		if actual != expected {
+			t.Errorf("[%s %s] expected route %d, got %d", test.method, test.path, expected, actual)
+		}
+	}
+}
+
+// hostPattern matches requests whose host has the given suffix.
+type hostPattern string
+
+func (h hostPattern) Host() string {
+	return string(h)
+}
+
+func (h hostPattern) Match(r *http.Request) *http.Request {
+	host := pattern.Host(r)
+	if host == string(h) || (h[0] == '.' && strings.HasSuffix(host, string(h))) {
+		return r
+	}
+	return nil
+}
+
+func TestRouterHosts(t *testing.T) {
+	t.Parallel()
+
+	var rt router
+	rt.add(hostPattern(".example.com"), intHandler(0))
+	rt.add(hostPattern("example.com"), intHandler(1))
+	rt.add(hostPattern(".eu.example.com"), intHandler(2))
+	rt.add(testPattern{mark: new(int), prefix: "/"}, intHandler(3))
+	rt.remove(0)
+
+	tests := []struct {
+		host  string
+		route int
+	}{
+		{"example.com", 0},
+		{"acme.example.com", 2},
+		{"acme.eu.example.com:8000", 1},
+		{"example.org", 2},
+	}
+	for _, test := range tests {
+		_, r := wr()
+		r.Host = test.host
+		r = r.WithContext(context.WithValue(r.Context(), internal.Path, "/"))
+		if h := rt.route(r).Context().Value(internal.Handler); h != intHandler(test.route+1) {
+			t.Errorf("[%s] expected route %d, got %v", test.host, test.route, h)
+		}
+	}
+}
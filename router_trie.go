@@ -8,17 +8,26 @@ import (
 	"strings"
 
 	"goji.io/internal"
+	"goji.io/pattern"
 )
 
 type router struct {
 	routes   []route
 	methods  map[string]*trieNode
 	wildcard trieNode
+	tree     segNode
+	// hosts maps host names (and host name suffixes, which begin with a
+	// ".") to the routes which must be matched against them.
+	hosts map[string][]int
 }
 
 type route struct {
 	Pattern
 	http.Handler
+	// methods is the set of methods the Pattern advertises, if any. The
+	// segment tree and host index (unlike the trie) are shared between all
+	// HTTP methods, so their routes must be filtered by it.
+	methods map[string]struct{}
 }
 
 type child struct {
@@ -32,18 +41,36 @@ type trieNode struct {
 }
 
 func (rt *router) add(p Pattern, h http.Handler) {
+	var methods map[string]struct{}
+	if hm, ok := p.(httpMethods); ok {
+		methods = hm.HTTPMethods()
+	}
 	i := len(rt.routes)
-	rt.routes = append(rt.routes, route{p, h})
+	rt.routes = append(rt.routes, route{Pattern: p, Handler: h, methods: methods})
+
+	var segs []pattern.Segment
+	if ps, ok := p.(pathSegments); ok {
+		segs = ps.PathSegments()
+	}
+	// Patterns which cannot describe their segments (such as those of
+	// some routes in Groups) return nil.
+	if segs != nil {
+		rt.tree.add(segs, i)
+		return
+	}
+	if h, ok := p.(host); ok && h.Host() != "" {
+		if rt.hosts == nil {
+			rt.hosts = make(map[string][]int)
+		}
+		rt.hosts[h.Host()] = append(rt.hosts[h.Host()], i)
+		return
+	}
 
 	var prefix string
 	if pp, ok := p.(pathPrefix); ok {
 		prefix = pp.PathPrefix()
 	}
 
-	var methods map[string]struct{}
-	if hm, ok := p.(httpMethods); ok {
-		methods = hm.HTTPMethods()
-	}
 	if methods == nil {
 		rt.wildcard.add(prefix, i)
 		for _, sub := range rt.methods {
@@ -63,7 +90,68 @@ func (rt *router) add(p Pattern, h http.Handler) {
 	}
 }
 
+func (rt *router) remove(idx int) {
+	rt.routes = append(rt.routes[:idx], rt.routes[idx+1:]...)
+	rt.tree.remove(idx)
+	for h, routes := range rt.hosts {
+		if routes = renumber(routes, idx); len(routes) > 0 {
+			rt.hosts[h] = routes
+		} else {
+			delete(rt.hosts, h)
+		}
+	}
+	rt.wildcard.remove(idx)
+	for method, tn := range rt.methods {
+		tn.remove(idx)
+		if !rt.hasMethod(method) {
+			delete(rt.methods, method)
+		}
+	}
+}
+
+// hasMethod reports whether any route explicitly asks for the given method.
+func (rt *router) hasMethod(method string) bool {
+	for _, route := range rt.routes {
+		if hm, ok := route.Pattern.(httpMethods); ok {
+			if _, ok := hm.HTTPMethods()[method]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (rt *router) setHandler(idx int, h http.Handler) {
+	rt.routes[idx].Handler = h
+}
+
+func (rt *router) clone() router {
+	clone := router{
+		routes:   append([]route(nil), rt.routes...),
+		wildcard: *rt.wildcard.clone(),
+		tree:     *rt.tree.clone(),
+	}
+	if rt.hosts != nil {
+		clone.hosts = make(map[string][]int, len(rt.hosts))
+		for h, routes := range rt.hosts {
+			clone.hosts[h] = append([]int(nil), routes...)
+		}
+	}
+	if rt.methods != nil {
+		clone.methods = make(map[string]*trieNode, len(rt.methods))
+		for method, tn := range rt.methods {
+			clone.methods[method] = tn.clone()
+		}
+	}
+	return clone
+}
+
 func (rt *router) route(r *http.Request) *http.Request {
+	return rt.trace(r, nil)
+}
+
+// trace routes the request, recording the routes it tries in t.
+func (rt *router) trace(r *http.Request, t *Trace) *http.Request {
 	tn := &rt.wildcard
 	if tn2, ok := rt.methods[r.Method]; ok {
 		tn = tn2
@@ -71,19 +159,10 @@ func (rt *router) route(r *http.Request) *http.Request {
 
 	ctx := r.Context()
 	path := ctx.Value(internal.Path).(string)
-	for path != "" {
-		i := sort.Search(len(tn.children), func(i int) bool {
-			return path[0] <= tn.children[i].prefix[0]
-		})
-		if i == len(tn.children) || !strings.HasPrefix(path, tn.children[i].prefix) {
-			break
-		}
-
-		path = path[len(tn.children[i].prefix):]
-		tn = tn.children[i].node
-	}
-	for _, i := range tn.routes {
-		if r2 := rt.routes[i].Match(r); r2 != nil {
+	for _, i := range rt.candidates(tn, r, path) {
+		r2 := rt.routes[i].Match(r)
+		t.record(path, rt.routes[i].Pattern, r2 != nil)
+		if r2 != nil {
 			return r2.WithContext(&match{
 				Context: r2.Context(),
 				p:       rt.routes[i].Pattern,
@@ -91,7 +170,95 @@ func (rt *router) route(r *http.Request) *http.Request {
 			})
 		}
 	}
-	return r.WithContext(&match{Context: ctx})
+	return r.WithContext(&match{Context: ctx, allowed: rt.allowed(r, path)})
+}
+
+// candidates returns the indices of the routes which might match the request,
+// given its path, in routing order. The trie must be the one for the request's
+// method.
+func (rt *router) candidates(tn *trieNode, r *http.Request, path string) []int {
+	routes := tn.lookup(path)
+	indexed := rt.indexed(r, path)
+	n := 0
+	for _, i := range indexed {
+		if contains(rt.routes[i].methods, r.Method) {
+			indexed[n] = i
+			n++
+		}
+	}
+	indexed = indexed[:n]
+	sortInts(indexed)
+	return merge(routes, indexed)
+}
+
+// indexed returns the indices of the routes, from among those indexed by the
+// segment tree or by host, which might match the request given its path. The
+// routes are returned in no particular order, and without regard to their HTTP
+// methods. The returned slice is never shared.
+func (rt *router) indexed(r *http.Request, path string) []int {
+	routes := rt.tree.lookup(path, nil)
+	if len(rt.hosts) == 0 {
+		return routes
+	}
+
+	h := pattern.Host(r)
+	routes = append(routes, rt.hosts[h]...)
+	for i := 0; i < len(h); i++ {
+		if h[i] == '.' {
+			routes = append(routes, rt.hosts[h[i:]]...)
+		}
+	}
+	return routes
+}
+
+// allowed returns the set of HTTP methods, other than the request's own, for
+// which some route would have matched the request, or nil if there are none.
+// Only methods which the matching route's Pattern advertises are considered.
+// Since a method only has its own trie if some Pattern asked for it, we only
+// need to consider those methods.
+func (rt *router) allowed(r *http.Request, path string) map[string]struct{} {
+	var methods map[string]struct{}
+	var r2 *http.Request
+	for method, tn := range rt.methods {
+		if method == r.Method {
+			continue
+		}
+		if r2 == nil {
+			r2 = r.WithContext(r.Context())
+		}
+		r2.Method = method
+		for _, i := range tn.lookup(path) {
+			if _, ok := rt.routes[i].methods[method]; !ok {
+				continue
+			}
+			if rt.routes[i].Match(r2) != nil {
+				if methods == nil {
+					methods = make(map[string]struct{})
+				}
+				methods[method] = struct{}{}
+				break
+			}
+		}
+	}
+
+	for _, i := range rt.indexed(r, path) {
+		for method := range rt.routes[i].methods {
+			if _, ok := methods[method]; ok || method == r.Method {
+				continue
+			}
+			if r2 == nil {
+				r2 = r.WithContext(r.Context())
+			}
+			r2.Method = method
+			if rt.routes[i].Match(r2) != nil {
+				if methods == nil {
+					methods = make(map[string]struct{})
+				}
+				methods[method] = struct{}{}
+			}
+		}
+	}
+	return methods
 }
 
 // We can be a teensy bit more efficient here: we're maintaining a sorted list,
@@ -165,6 +332,32 @@ func (tn *trieNode) add(prefix string, idx int) {
 	sort.Sort(byPrefix(tn.children))
 }
 
+// lookup returns the indices of the routes whose PathPrefix is a prefix of the
+// given path.
+func (tn *trieNode) lookup(path string) []int {
+	for path != "" {
+		i := sort.Search(len(tn.children), func(i int) bool {
+			return path[0] <= tn.children[i].prefix[0]
+		})
+		if i == len(tn.children) || !strings.HasPrefix(path, tn.children[i].prefix) {
+			break
+		}
+
+		path = path[len(tn.children[i].prefix):]
+		tn = tn.children[i].node
+	}
+	return tn.routes
+}
+
+// remove removes the route with the given index from the trie, renumbering the
+// routes that follow it.
+func (tn *trieNode) remove(idx int) {
+	tn.routes = renumber(tn.routes, idx)
+	for i := range tn.children {
+		tn.children[i].node.remove(idx)
+	}
+}
+
 func (tn *trieNode) clone() *trieNode {
 	clone := new(trieNode)
 	clone.routes = append(clone.routes, tn.routes...)
@@ -0,0 +1,89 @@
+package goji
+
+import "net/http"
+
+/*
+Route describes a single route registered on a Mux with Handle. Routes are
+read-only views of the Mux's routing table: modifying a Route does not affect
+routing.
+*/
+type Route struct {
+	// Pattern is the Pattern the route was registered with.
+	Pattern Pattern
+	// Handler is the http.Handler the route was registered with (or
+	// most recently given with Registration.SetHandler).
+	Handler http.Handler
+	// Index is the position of the route in the Mux's routing order. The
+	// first route registered has index 0.
+	Index int
+}
+
+/*
+Routes returns the routes registered on the Mux, in the order in which they are
+considered during routing. The returned slice is a copy, and may be freely
+modified by the caller.
+
+Routes does not descend into other Muxes registered as route Handlers; see Walk
+for a function that does.
+*/
+func (m *Mux) Routes() []Route {
+	return m.current().routes()
+}
+
+func (m *Mux) routes() []Route {
+	routes := make([]Route, len(m.entries))
+	for i, e := range m.entries {
+		routes[i] = Route{
+			Pattern: e.reg.pattern,
+			Handler: e.handler,
+			Index:   i,
+		}
+	}
+	return routes
+}
+
+/*
+WalkFunc is the type of the function called by Walk for each route. The parents
+argument lists the routes, outermost first, that lead from the Mux Walk was
+called on to the Mux containing the route. It is empty for routes registered on
+the top-level Mux, and must not be retained or modified by the WalkFunc.
+
+If the WalkFunc returns a non-nil error, Walk stops and returns that error.
+*/
+type WalkFunc func(route Route, parents []Route) error
+
+/*
+Walk calls fn for every route registered on the Mux, in routing order. Whenever
+a route's Handler is itself a *Mux (for instance, one created with SubMux), Walk
+recursively visits its routes immediately after visiting the route that leads
+to it. This makes it possible to enumerate an application's complete routing
+table:
+
+	root.Walk(func(route goji.Route, parents []goji.Route) error {
+		for _, parent := range parents {
+			fmt.Print(parent.Pattern, " -> ")
+		}
+		fmt.Println(route.Pattern)
+		return nil
+	})
+
+It is not safe to call Walk concurrently with the registration of routes unless
+the Mux is in copy-on-write mode.
+*/
+func (m *Mux) Walk(fn WalkFunc) error {
+	return m.walk(fn, nil)
+}
+
+func (m *Mux) walk(fn WalkFunc, parents []Route) error {
+	for _, route := range m.Routes() {
+		if err := fn(route, parents); err != nil {
+			return err
+		}
+		if sub, ok := route.Handler.(*Mux); ok {
+			if err := sub.walk(fn, append(parents[:len(parents):len(parents)], route)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package goji
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMuxRoutes(t *testing.T) {
+	t.Parallel()
+
+	m := NewMux()
+	m.Handle(testPattern{prefix: "/a"}, intHandler(0))
+	m.Handle(testPattern{prefix: "/b"}, intHandler(1))
+
+	expected := []Route{
+		{testPattern{prefix: "/a"}, intHandler(0), 0},
+		{testPattern{prefix: "/b"}, intHandler(1), 1},
+	}
+	if routes := m.Routes(); !reflect.DeepEqual(routes, expected) {
+		t.Errorf("expected %v, got %v", expected, routes)
+	}
+}
+
+func TestWalk(t *testing.T) {
+	t.Parallel()
+
+	sub := SubMux()
+	sub.Handle(testPattern{prefix: "/c"}, intHandler(2))
+	sub.Handle(testPattern{prefix: "/d"}, intHandler(3))
+	root := NewMux()
+	root.Handle(testPattern{prefix: "/a"}, intHandler(0))
+	root.Handle(testPattern{prefix: "/b"}, sub)
+	root.Handle(testPattern{prefix: "/e"}, intHandler(4))
+
+	var seen []string
+	err := root.Walk(func(route Route, parents []Route) error {
+		var path string
+		for _, parent := range parents {
+			path += parent.Pattern.(testPattern).prefix
+		}
+		seen = append(seen, path+route.Pattern.(testPattern).prefix)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{"/a", "/b", "/b/c", "/b/d", "/e"}
+	if !reflect.DeepEqual(seen, expected) {
+		t.Errorf("expected %v, got %v", expected, seen)
+	}
+
+	stop := errors.New("stop")
+	n := 0
+	err = root.Walk(func(route Route, parents []Route) error {
+		n++
+		if len(parents) > 0 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || n != 3 {
+		t.Errorf("expected walk to stop after 3 routes, got err=%v after %d", err, n)
+	}
+}
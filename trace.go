@@ -0,0 +1,149 @@
+package goji
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"goji.io/internal"
+)
+
+/*
+TraceHeader is the name of the HTTP header used to request routing traces and to
+report them. See TraceRequests.
+*/
+const TraceHeader = "Goji-Trace"
+
+/*
+TraceStep records a single Pattern the router tried while routing a request.
+*/
+type TraceStep struct {
+	// Depth is the number of Muxes which had already routed the request
+	// when the Pattern was tried: 0 for the root Mux, 1 for a SubMux
+	// directly beneath it, and so on.
+	Depth int
+	// Path is the path (see pattern.Path) the Pattern was matched against.
+	Path string
+	// Pattern is the Pattern that was tried, as it was registered.
+	Pattern Pattern
+	// Matched reports whether the Pattern matched the request.
+	Matched bool
+}
+
+/*
+String formats the step on a single line.
+*/
+func (s TraceStep) String() string {
+	result := "miss"
+	if s.Matched {
+		result = "match"
+	}
+	return fmt.Sprintf("%d %q %v %s", s.Depth, s.Path, s.Pattern, result)
+}
+
+/*
+Trace records the routing decisions made for a single request. Traces are
+obtained from a request's context using middleware.Trace.
+
+Steps lists, in order, every Pattern tried by every Mux the request was routed
+through. Patterns which the router's optimizations (for instance, those
+described in the documentation for Pattern) ruled out without calling Match do
+not appear. Patterns tried while looking for a redirect (see Redirect) or for
+the methods to list in an Allow header do not appear either.
+
+A Trace is not safe for concurrent use.
+*/
+type Trace struct {
+	Steps []TraceStep
+
+	depth int
+}
+
+/*
+String formats the trace with one step per line.
+*/
+func (t *Trace) String() string {
+	return strings.Join(t.lines(), "\n")
+}
+
+func (t *Trace) lines() []string {
+	lines := make([]string, len(t.Steps))
+	for i, s := range t.Steps {
+		lines[i] = s.String()
+	}
+	return lines
+}
+
+// record appends a step to the trace. It is a no-op on a nil Trace, which is
+// what routers are given when the request is not being traced.
+func (t *Trace) record(path string, p Pattern, matched bool) {
+	if t == nil {
+		return
+	}
+	t.Steps = append(t.Steps, TraceStep{
+		Depth:   t.depth,
+		Path:    path,
+		Pattern: p,
+		Matched: matched,
+	})
+}
+
+// traced is set once any Mux has enabled tracing, after which every Mux looks
+// for a Trace in the context of the requests it routes. Until then, routing
+// does not pay for the lookup.
+var traced int32
+
+/*
+TraceRequests controls whether the Mux traces requests which include a
+Goji-Trace header (see TraceHeader) with a non-empty value.
+
+Traced requests carry a Trace in their context, which records every Pattern
+tried while routing the request, and whether it matched. The Trace is shared
+with any SubMux (or other Mux) the request is subsequently routed to, whether or
+not it has tracing enabled, so enabling tracing on the root Mux is sufficient
+to trace an entire tree of Muxes. Each Mux also copies the Trace, one step per
+value, into the Goji-Trace header of the response before passing the request to
+its middleware stack, so the trace is visible to clients as long as the
+response's headers were not written before the last Mux routed the request.
+
+Since traces reveal the application's routes to clients, tracing is disabled by
+default, and should only be enabled in development. Building with the
+"goji_trace" build tag instead traces (and reports) every request routed by any
+Mux. It is not safe to call TraceRequests concurrently with requests unless the
+Mux is in copy-on-write mode.
+*/
+func (m *Mux) TraceRequests(enabled bool) {
+	if enabled {
+		atomic.StoreInt32(&traced, 1)
+	}
+	m.update(func(m *Mux) {
+		m.tracing = enabled
+	})
+}
+
+// trace returns the request's Trace, creating one if this Mux should start
+// tracing the request, and the request carrying it. The returned Trace is nil
+// when the request is not being traced.
+func (m *Mux) trace(r *http.Request) (*Trace, *http.Request) {
+	if !traceAll && atomic.LoadInt32(&traced) == 0 {
+		return nil, r
+	}
+	ctx := r.Context()
+	if t, ok := ctx.Value(internal.Trace).(*Trace); ok {
+		return t, r
+	}
+	if traceAll || m.tracing && r.Header.Get(TraceHeader) != "" {
+		t := &Trace{}
+		return t, r.WithContext(context.WithValue(ctx, internal.Trace, t))
+	}
+	return nil, r
+}
+
+// routed is called once a Mux has routed the request. It reports the trace so
+// far in the response's headers.
+func (t *Trace) routed(w http.ResponseWriter) {
+	t.depth++
+	w.Header()[TraceHeader] = t.lines()
+}
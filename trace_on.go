@@ -0,0 +1,6 @@
+// +build goji_trace
+
+package goji
+
+// traceAll is set by the goji_trace build tag. See TraceRequests.
+const traceAll = true
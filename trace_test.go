@@ -0,0 +1,61 @@
+package goji
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"goji.io/internal"
+	"goji.io/pat"
+)
+
+func TestTraceRequests(t *testing.T) {
+	t.Parallel()
+
+	root := NewMux()
+	root.TraceRequests(true)
+	users := SubMux()
+	users.Handle(boolPattern(false), intHandler(0))
+	users.Handle(pat.Get("/:name"), intHandler(0))
+	root.Handle(pat.New("/users/*"), users)
+
+	var trace *Trace
+	root.Use(func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			trace, _ = r.Context().Value(internal.Trace).(*Trace)
+			h.ServeHTTP(w, r)
+		})
+	})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/users/carl", nil)
+	root.ServeHTTP(w, r)
+	// With the goji_trace build tag, every request is traced.
+	if !traceAll {
+		if trace != nil {
+			t.Errorf("expected no trace without header, got %v", trace)
+		}
+		if h := w.Header()[TraceHeader]; h != nil {
+			t.Errorf("expected no trace header, got %q", h)
+		}
+	}
+
+	r.Header.Set(TraceHeader, "1")
+	w = httptest.NewRecorder()
+	root.ServeHTTP(w, r)
+	if trace == nil {
+		t.Fatal("expected a trace")
+	}
+	expected := []string{
+		`0 "/users/carl" /users/* match`,
+		`1 "/carl" false miss`,
+		`1 "/carl" /:name match`,
+	}
+	if h := w.Header()[TraceHeader]; !reflect.DeepEqual(h, expected) {
+		t.Errorf("trace header: expected %q, got %q", expected, h)
+	}
+	if len(trace.Steps) != 3 || trace.Steps[2].Pattern == nil || trace.Steps[2].Depth != 1 {
+		t.Errorf("unexpected trace %v", trace)
+	}
+}
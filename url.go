@@ -0,0 +1,90 @@
+package goji
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+URL returns the path of the route registered on the Mux with the given name (see
+HandleNamed), binding the Pattern's variables to the values in vars. The
+route's Pattern must support building paths (Goji's pat subpackage does so
+through its Reverse method); URL returns an error if it does not, if no route
+has the given name, or if the Pattern rejects the given variables.
+
+If the Mux has no route of the given name, URL searches Muxes that are
+registered as route Handlers (for instance, those created with SubMux), in
+routing order. When a named route is found in such a Mux, the path is built by
+joining the path built from the Pattern of the route leading to that Mux with
+the path built by the Mux itself. This requires the leading Pattern to be able
+to list the variables it binds, which Goji's pat subpackage supports using its
+Variables method. For example:
+
+	root := goji.NewMux()
+	users := goji.SubMux()
+	root.Handle(pat.New("/users/:name/*"), users)
+	users.HandleNamed("photo", pat.Get("/photos/:id"), photo)
+
+	// "/users/carl/photos/42"
+	root.URL("photo", map[string]string{"name": "carl", "id": "42"})
+*/
+func (m *Mux) URL(name string, vars map[string]string) (string, error) {
+	path, ok, err := m.url(name, vars)
+	if !ok {
+		return "", fmt.Errorf("goji: no route named %q", name)
+	}
+	return path, err
+}
+
+func (m *Mux) url(name string, vars map[string]string) (string, bool, error) {
+	m = m.current()
+	if reg, ok := m.names[name]; ok {
+		rv, ok := reg.pattern.(reverser)
+		if !ok {
+			return "", true, fmt.Errorf("goji: pattern for route %q cannot build paths", name)
+		}
+		path, err := rv.Reverse(vars)
+		return path, true, err
+	}
+
+	for _, route := range m.routes() {
+		sub, ok := route.Handler.(*Mux)
+		if !ok {
+			continue
+		}
+		rv, ok := route.Pattern.(reverser)
+		if !ok {
+			continue
+		}
+		vs, ok := route.Pattern.(variables)
+		if !ok {
+			continue
+		}
+
+		own := make(map[string]string)
+		rest := make(map[string]string, len(vars))
+		for k, v := range vars {
+			rest[k] = v
+		}
+		for _, k := range vs.Variables() {
+			if v, ok := rest[string(k)]; ok {
+				own[string(k)] = v
+				delete(rest, string(k))
+			}
+		}
+
+		suffix, ok, err := sub.url(name, rest)
+		if !ok {
+			continue
+		} else if err != nil {
+			return "", true, err
+		}
+		prefix, err := rv.Reverse(own)
+		if err != nil {
+			return "", true, err
+		}
+		return strings.TrimSuffix(prefix, "/") + suffix, true, nil
+	}
+
+	return "", false, nil
+}
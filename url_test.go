@@ -0,0 +1,87 @@
+package goji
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"goji.io/pattern"
+)
+
+// revPattern is a Pattern which builds paths consisting of a literal prefix
+// followed by the values of its variables, each preceded by a slash.
+type revPattern struct {
+	prefix string
+	vars   []pattern.Variable
+}
+
+func (revPattern) Match(r *http.Request) *http.Request {
+	return nil
+}
+
+func (p revPattern) Reverse(vars map[string]string) (string, error) {
+	if len(vars) != len(p.vars) {
+		return "", errors.New("wrong number of variables")
+	}
+	path := p.prefix
+	for _, name := range p.vars {
+		v, ok := vars[string(name)]
+		if !ok {
+			return "", errors.New("missing variable")
+		}
+		path += "/" + v
+	}
+	return path, nil
+}
+
+func (p revPattern) Variables() []pattern.Variable {
+	return p.vars
+}
+
+func TestURL(t *testing.T) {
+	t.Parallel()
+
+	photos := SubMux()
+	photos.HandleNamed("photo", revPattern{"/photos", []pattern.Variable{"id"}}, intHandler(0))
+	root := NewMux()
+	root.HandleNamed("home", revPattern{prefix: "/"}, intHandler(1))
+	root.HandleNamed("opaque", boolPattern(true), intHandler(2))
+	root.Handle(boolPattern(true), SubMux())
+	root.Handle(revPattern{"/users", []pattern.Variable{"name"}}, photos)
+
+	tests := []struct {
+		name string
+		vars map[string]string
+		path string
+		ok   bool
+	}{
+		{"home", nil, "/", true},
+		{"home", map[string]string{"extra": "1"}, "", false},
+		{"opaque", nil, "", false},
+		{"photo", map[string]string{"name": "carl", "id": "42"}, "/users/carl/photos/42", true},
+		{"photo", map[string]string{"name": "carl"}, "", false},
+		{"missing", nil, "", false},
+	}
+	for _, test := range tests {
+		path, err := root.URL(test.name, test.vars)
+		if (err == nil) != test.ok {
+			t.Errorf("[%s %v] err=%v, expected ok=%v", test.name, test.vars, err, test.ok)
+		}
+		if path != test.path {
+			t.Errorf("[%s %v] path=%q, expected %q", test.name, test.vars, path, test.path)
+		}
+	}
+}
+
+func TestHandleNamedDuplicate(t *testing.T) {
+	t.Parallel()
+
+	m := NewMux()
+	m.HandleNamed("home", boolPattern(true), intHandler(0))
+	defer func() {
+		if recover() == nil {
+			t.Error("expected duplicate route name to panic")
+		}
+	}()
+	m.HandleNamed("home", boolPattern(true), intHandler(1))
+}